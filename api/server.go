@@ -4,22 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"go.rumenx.com/sixtysix/engine"
+	"go.rumenx.com/sixtysix/i18n"
 )
 
 // Server is a minimal HTTP server exposing the engine.
 type Server struct {
 	Engine *engine.Engine
-	mux    *http.ServeMux
+	// Messages translates error messages for the Accept-Language of each
+	// request; nil leaves them in English.
+	Messages *i18n.Catalog
+	mux      *http.ServeMux
 }
 
 func New(e *engine.Engine) *Server {
-	s := &Server{Engine: e, mux: http.NewServeMux()}
+	s := &Server{Engine: e, Messages: i18n.Default(), mux: http.NewServeMux()}
 	s.routes()
 	return s
 }
@@ -33,92 +38,459 @@ func (s *Server) routes() {
 	// GET /games -> list
 	s.mux.HandleFunc("/games", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
 			return
 		}
 		writeJSON(w, http.StatusOK, map[string]any{"games": s.Engine.Games()})
 	})
 
-	// POST /sessions?game=NAME&seed=0
+	// GET /messages -> message templates for the negotiated locale
+	s.mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
+			return
+		}
+		catalog := s.Messages
+		if catalog == nil {
+			catalog = i18n.New("en")
+		}
+		locale := catalog.Match(r.Header.Get("Accept-Language"))
+		if l := r.URL.Query().Get("lang"); l != "" {
+			locale = catalog.Match(l)
+		}
+		w.Header().Set("Content-Language", locale)
+		writeJSON(w, http.StatusOK, map[string]any{"locale": locale, "messages": catalog.Messages(locale)})
+	})
+
+	// POST /sessions?game=NAME&seed=0&rated=false
 	s.mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			game := r.URL.Query().Get("game")
 			if game == "" {
-				http.Error(w, "missing game", http.StatusBadRequest)
+				s.writeError(w, r, http.StatusBadRequest, "missingGame", "missing game")
 				return
 			}
 			seedStr := r.URL.Query().Get("seed")
 			var seed int64
-			if seedStr != "" {
+			seeded := seedStr != ""
+			if seeded {
 				if v, err := strconv.ParseInt(seedStr, 10, 64); err == nil {
 					seed = v
 				}
 			}
-			sess, err := s.Engine.CreateSession(r.Context(), game, seed)
+			var rules json.RawMessage
+			if v := r.URL.Query().Get("rules"); v != "" {
+				rules = json.RawMessage(v)
+			}
+			rated, _ := strconv.ParseBool(r.URL.Query().Get("rated"))
+			// optional JSON body: {"seed":42,"rules":{...},"rated":true}, or
+			// {"state":{...}} to start from a custom position
+			var body struct {
+				Seed  *int64          `json:"seed"`
+				Rules json.RawMessage `json:"rules"`
+				Rated bool            `json:"rated"`
+				State json.RawMessage `json:"state"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+				s.writeError(w, r, http.StatusBadRequest, "invalidJSON", "invalid json")
+				return
+			}
+			if body.Seed != nil {
+				seed, seeded = *body.Seed, true
+			}
+			if len(body.Rules) > 0 {
+				rules = body.Rules
+			}
+			rated = rated || body.Rated
+			// a custom state carries its own rules and deal, and puzzles
+			// cannot be rated
+			if len(body.State) > 0 && (seeded || len(rules) > 0 || rated) {
+				s.writeError(w, r, http.StatusBadRequest, "stateWithOptions", "state cannot be combined with seed, rules or rated")
+				return
+			}
+			var sess engine.Session
+			var err error
+			switch {
+			case len(body.State) > 0:
+				sess, err = s.Engine.CreateSessionFromState(r.Context(), game, body.State)
+			case rated:
+				sess, err = s.Engine.CreateRatedSession(r.Context(), game, seed, rules)
+			default:
+				sess, err = s.Engine.CreateSessionWithOptions(r.Context(), game, seed, rules)
+			}
 			if err != nil {
-				handleEngineError(w, err)
+				s.handleEngineError(w, r, err)
 				return
 			}
-			writeJSON(w, http.StatusCreated, sess)
+			writeSession(w, http.StatusCreated, sess)
 		case http.MethodGet:
 			game := r.URL.Query().Get("game")
 			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 			list, err := s.Engine.ListSessions(r.Context(), game, offset, limit)
 			if err != nil {
-				handleEngineError(w, err)
+				s.handleEngineError(w, r, err)
 				return
 			}
+			for i := range list {
+				list[i] = s.present(list[i], -1)
+			}
 			writeJSON(w, http.StatusOK, map[string]any{"sessions": list})
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
 		}
 	})
 
-	// GET/POST/DELETE /sessions/{id}
+	// GET/POST/DELETE /sessions/{id}, GET /sessions/{id}/actions,
+	// GET /sessions/{id}/history, POST /sessions/{id}/takeback[/accept|/decline],
+	// POST /sessions/{id}/fork
 	s.mux.HandleFunc("/sessions/", func(w http.ResponseWriter, r *http.Request) {
-		id := strings.TrimPrefix(r.URL.Path, "/sessions/")
+		id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/sessions/"), "/")
 		if id == "" {
 			http.NotFound(w, r)
 			return
 		}
+		switch sub {
+		case "":
+		case "actions":
+			s.handleActions(w, r, id)
+			return
+		case "history":
+			s.handleHistory(w, r, id)
+			return
+		case "takeback", "takeback/accept", "takeback/decline":
+			s.handleTakeback(w, r, id, sub)
+			return
+		case "fork":
+			s.handleFork(w, r, id)
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			sess, err := s.Engine.GetSession(r.Context(), id)
 			if err != nil {
-				handleEngineError(w, err)
+				s.handleEngineError(w, r, err)
+				return
+			}
+			seat, ok := s.resolveSeat(w, r, sess)
+			if !ok {
 				return
 			}
-			writeJSON(w, http.StatusOK, sess)
+			writeSession(w, http.StatusOK, s.present(sess, seat))
 		case http.MethodPost: // apply action
+			sess, err := s.Engine.GetSession(r.Context(), id)
+			if err != nil {
+				s.handleEngineError(w, r, err)
+				return
+			}
+			seat, ok := s.resolveSeat(w, r, sess)
+			if !ok {
+				return
+			}
 			var a engine.Action
 			if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
-				http.Error(w, "invalid json", http.StatusBadRequest)
+				s.writeError(w, r, http.StatusBadRequest, "invalidJSON", "invalid json")
+				return
+			}
+			expected, ok := s.ifMatch(w, r)
+			if !ok {
 				return
 			}
-			sess, err := s.Engine.ApplyAction(context.Background(), id, a)
+			if expected != 0 {
+				a.ExpectedVersion = expected
+			}
+			if len(sess.Tokens) > 0 {
+				if seat < 0 {
+					s.writeError(w, r, http.StatusUnauthorized, "tokenRequired", "seat token required")
+					return
+				}
+				a.Actor = strconv.Itoa(seat)
+			}
+			sess, err = s.Engine.ApplyAction(context.Background(), id, a)
 			if err != nil {
-				handleEngineError(w, err)
+				s.handleEngineError(w, r, err)
 				return
 			}
-			writeJSON(w, http.StatusOK, sess)
+			writeSession(w, http.StatusOK, s.present(sess, seat))
 		case http.MethodDelete:
+			sess, err := s.Engine.GetSession(r.Context(), id)
+			if err != nil {
+				s.handleEngineError(w, r, err)
+				return
+			}
+			seat, ok := s.resolveSeat(w, r, sess)
+			if !ok {
+				return
+			}
+			if len(sess.Tokens) > 0 && seat < 0 {
+				s.writeError(w, r, http.StatusUnauthorized, "tokenRequired", "seat token required")
+				return
+			}
 			if err := s.Engine.DeleteSession(r.Context(), id); err != nil {
-				handleEngineError(w, err)
+				s.handleEngineError(w, r, err)
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
 		}
 	})
 }
 
+// handleActions serves GET /sessions/{id}/actions: the legal actions for the
+// caller's seat (empty when it is not their turn).
+func (s *Server) handleActions(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
+		return
+	}
+	sess, err := s.Engine.GetSession(r.Context(), id)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	seat, ok := s.resolveSeat(w, r, sess)
+	if !ok {
+		return
+	}
+	if len(sess.Tokens) > 0 && seat < 0 {
+		// spectators would otherwise learn the hand of the seat to move
+		writeJSON(w, http.StatusOK, map[string]any{"actions": []engine.Action{}})
+		return
+	}
+	var actor string
+	if seat >= 0 {
+		actor = strconv.Itoa(seat)
+	}
+	actions, err := s.Engine.LegalActions(r.Context(), id, actor)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"actions": actions})
+}
+
+// handleHistory serves GET /sessions/{id}/history: the session's records, or
+// with ?version=N the session replayed to that version. The seed or starting
+// state reveals every hand, so it is withheld until the session is finished.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
+		return
+	}
+	sess, err := s.Engine.GetSession(r.Context(), id)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	seat, ok := s.resolveSeat(w, r, sess)
+	if !ok {
+		return
+	}
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			s.writeError(w, r, http.StatusBadRequest, "invalidVersion", "invalid version")
+			return
+		}
+		past, err := s.Engine.Replay(r.Context(), id, version)
+		if err != nil {
+			s.handleEngineError(w, r, err)
+			return
+		}
+		writeSession(w, http.StatusOK, s.present(past, seat))
+		return
+	}
+	history, err := s.Engine.History(r.Context(), id)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	if !sess.Finished {
+		for i := range history {
+			history[i].Seed, history[i].State = 0, nil
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"history": history})
+}
+
+// handleTakeback serves POST /sessions/{id}/takeback, which asks the other
+// seats to undo the last {"actions":N} actions (default 1), and POST
+// .../takeback/accept and .../takeback/decline, which answer that request.
+func (s *Server) handleTakeback(w http.ResponseWriter, r *http.Request, id, sub string) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
+		return
+	}
+	sess, err := s.Engine.GetSession(r.Context(), id)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	seat, ok := s.resolveSeat(w, r, sess)
+	if !ok {
+		return
+	}
+	if len(sess.Tokens) > 0 && seat < 0 {
+		s.writeError(w, r, http.StatusUnauthorized, "tokenRequired", "seat token required")
+		return
+	}
+	switch sub {
+	case "takeback":
+		body := struct {
+			Actions int `json:"actions"`
+		}{Actions: 1}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			s.writeError(w, r, http.StatusBadRequest, "invalidJSON", "invalid json")
+			return
+		}
+		sess, err = s.Engine.RequestTakeback(r.Context(), id, seat, body.Actions)
+	default:
+		// If-Match ties the answer to the request the seat saw
+		expected, ok := s.ifMatch(w, r)
+		if !ok {
+			return
+		}
+		sess, err = s.Engine.AnswerTakeback(r.Context(), id, seat, sub == "takeback/accept", expected)
+	}
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	writeSession(w, http.StatusOK, s.present(sess, seat))
+}
+
+// handleFork serves POST /sessions/{id}/fork?version=N: a new session from the
+// state at version N (default: the last). The fork starts with full state and
+// fresh seat tokens, so only finished sessions may be forked.
+func (s *Server) handleFork(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, r, http.StatusMethodNotAllowed, "methodNotAllowed", "method not allowed")
+		return
+	}
+	var version int
+	if v := r.URL.Query().Get("version"); v != "" {
+		var err error
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			s.writeError(w, r, http.StatusBadRequest, "invalidVersion", "invalid version")
+			return
+		}
+	}
+	sess, err := s.Engine.GetSession(r.Context(), id)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	if !sess.Finished {
+		s.writeError(w, r, http.StatusConflict, "notFinished", "session not finished")
+		return
+	}
+	fork, err := s.Engine.Fork(r.Context(), id, version)
+	if err != nil {
+		s.handleEngineError(w, r, err)
+		return
+	}
+	writeSession(w, http.StatusCreated, fork)
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mux.ServeHTTP(w, r)
 }
 
+// seatParam parses the optional ?seat= query parameter. It returns -1 when
+// absent and writes a 400 response when malformed.
+func (s *Server) seatParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("seat")
+	if v == "" {
+		return -1, true
+	}
+	seat, err := strconv.Atoi(v)
+	if err != nil || seat < 0 {
+		s.writeError(w, r, http.StatusBadRequest, "invalidSeat", "invalid seat")
+		return 0, false
+	}
+	return seat, true
+}
+
+// seatToken extracts the seat token from the X-Seat-Token header or a
+// bearer Authorization header.
+func seatToken(r *http.Request) string {
+	if t := r.Header.Get("X-Seat-Token"); t != "" {
+		return t
+	}
+	if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(t)
+	}
+	return ""
+}
+
+// resolveSeat determines the caller's seat. For sessions with seat tokens the
+// seat comes from the token (and ?seat=, if given, must agree); callers
+// without a token are spectators (-1).
+func (s *Server) resolveSeat(w http.ResponseWriter, r *http.Request, sess engine.Session) (int, bool) {
+	seat, ok := s.seatParam(w, r)
+	if !ok {
+		return 0, false
+	}
+	if len(sess.Tokens) == 0 {
+		return seat, true
+	}
+	tok := seatToken(r)
+	if tok == "" {
+		if seat >= 0 {
+			s.writeError(w, r, http.StatusUnauthorized, "tokenRequired", "seat token required")
+			return 0, false
+		}
+		return -1, true
+	}
+	authed, ok := sess.SeatOf(tok)
+	if !ok {
+		s.handleEngineError(w, r, engine.ErrUnauthorized)
+		return 0, false
+	}
+	if seat >= 0 && seat != authed {
+		s.writeError(w, r, http.StatusForbidden, "seatMismatch", "token does not match seat")
+		return 0, false
+	}
+	return authed, true
+}
+
+// ifMatch parses an If-Match header carrying a session ETag. It returns 0 when
+// the header is absent or "*", and writes a 400 response when malformed.
+func (s *Server) ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return 0, true
+	}
+	v, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(h, "W/"), `"`))
+	if err != nil || v <= 0 {
+		s.writeError(w, r, http.StatusBadRequest, "invalidIfMatch", "invalid If-Match")
+		return 0, false
+	}
+	return v, true
+}
+
+// writeSession writes a session with its version as ETag.
+func writeSession(w http.ResponseWriter, status int, sess engine.Session) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(sess.Version)+`"`)
+	writeJSON(w, status, sess)
+}
+
+// present prepares a session for a caller at seat: tokens are stripped and the
+// state is redacted, except for token-less sessions read without ?seat=.
+func (s *Server) present(sess engine.Session, seat int) engine.Session {
+	redact := seat >= 0 || len(sess.Tokens) > 0
+	sess.Tokens = nil
+	if !redact {
+		return sess
+	}
+	return s.Engine.View(sess, seat)
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -127,15 +499,52 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	}
 }
 
-func handleEngineError(w http.ResponseWriter, err error) {
+// writeError writes an API-level error in the same JSON shape as engine
+// errors.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	s.writeErr(w, r, status, engine.NewError(code, message))
+}
+
+// handleEngineError maps err to a status and writes it as JSON
+// {"error","code","details"}. Errors that are not *engine.Error get the code
+// "invalid".
+func (s *Server) handleEngineError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadRequest
 	switch {
-	case errors.Is(err, engine.ErrGameNotFound):
-		http.Error(w, err.Error(), http.StatusNotFound)
-	case errors.Is(err, engine.ErrSessionNotFound):
-		http.Error(w, err.Error(), http.StatusNotFound)
-	case errors.Is(err, engine.ErrConflict):
-		http.Error(w, err.Error(), http.StatusConflict)
-	default:
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, engine.ErrGameNotFound), errors.Is(err, engine.ErrSessionNotFound),
+		errors.Is(err, engine.ErrVersionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, engine.ErrConflict), errors.Is(err, engine.ErrGameOver),
+		errors.Is(err, engine.ErrNoTakeback):
+		status = http.StatusConflict
+	case errors.Is(err, engine.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, engine.ErrNotYourTurn), errors.Is(err, engine.ErrRated),
+		errors.Is(err, engine.ErrOwnTakeback):
+		status = http.StatusForbidden
+	}
+	var e *engine.Error
+	if !errors.As(err, &e) {
+		e = engine.NewError("invalid", err.Error())
+	}
+	s.writeErr(w, r, status, e)
+}
+
+// writeErr writes e with its message translated for the request's
+// Accept-Language, when the catalog has a translation for its code. A specific
+// English message that the translation replaces is kept in details.reason.
+func (s *Server) writeErr(w http.ResponseWriter, r *http.Request, status int, e *engine.Error) {
+	if s.Messages != nil {
+		locale := s.Messages.Match(r.Header.Get("Accept-Language"))
+		if msg, ok := s.Messages.Message(locale, e.Code, e.Details); ok {
+			c := *e
+			if e.Specific() {
+				c = *e.With("reason", e.Message)
+			}
+			c.Message = msg
+			e = &c
+		}
+		w.Header().Set("Content-Language", locale)
 	}
+	writeJSON(w, status, e)
 }
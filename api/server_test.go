@@ -2,10 +2,12 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"go.rumenx.com/sixtysix"
@@ -42,15 +44,76 @@ func TestServer_Flow(t *testing.T) {
 		t.Fatalf("json: %v", err)
 	}
 	id := sess["id"].(string)
+	tokens := sess["tokens"].([]any)
+
+	// legal actions for the seat to move
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+id+"/actions", nil)
+	req.Header.Set("X-Seat-Token", tokens[0].(string))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"play"`)) {
+		t.Fatalf("actions: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+id+"/actions", nil)
+	req.Header.Set("X-Seat-Token", tokens[1].(string))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"actions":[]`)) {
+		t.Fatalf("actions out of turn: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// actions require a seat token
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+id, bytes.NewBufferString(`{"type":"closeStock"}`))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("apply without token: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// seat 1 is not to move
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+id, bytes.NewBufferString(`{"type":"closeStock"}`))
+	req.Header.Set("Authorization", "Bearer "+tokens[1].(string))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"notYourTurn"`)) {
+		t.Fatalf("apply out of turn: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// invalid moves come back as structured errors
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+id, bytes.NewBufferString(`{"type":"play","payload":{"card":"XX"}}`))
+	req.Header.Set("X-Seat-Token", tokens[0].(string))
+	srv.ServeHTTP(rr, req)
+	var apiErr struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &apiErr); err != nil || rr.Code != http.StatusBadRequest || apiErr.Code != "invalidCard" || apiErr.Error != `sixtysix: invalid card "XX"` {
+		t.Fatalf("invalid play: %d %s", rr.Code, rr.Body.String())
+	}
 
 	// apply a simple no-payload action
 	body := bytes.NewBufferString(`{"type":"closeStock"}`)
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodPost, "/sessions/"+id, body)
+	req.Header.Set("X-Seat-Token", tokens[0].(string))
 	srv.ServeHTTP(rr, req)
-	if rr.Code != http.StatusOK {
+	if rr.Code != http.StatusOK || bytes.Contains(rr.Body.Bytes(), []byte("tokens")) {
 		t.Fatalf("apply: %d %s", rr.Code, rr.Body.String())
 	}
+	if etag := rr.Header().Get("ETag"); etag != `"2"` {
+		t.Fatalf("etag: %q", etag)
+	}
+
+	// stale If-Match
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+id, bytes.NewBufferString(`{"type":"declare","payload":{"suit":0}}`))
+	req.Header.Set("X-Seat-Token", tokens[0].(string))
+	req.Header.Set("If-Match", `"1"`)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("stale if-match: %d %s", rr.Code, rr.Body.String())
+	}
 
 	// get session
 	rr = httptest.NewRecorder()
@@ -60,9 +123,36 @@ func TestServer_Flow(t *testing.T) {
 		t.Fatalf("get: %d %s", rr.Code, rr.Body.String())
 	}
 
+	// a seat view requires that seat's token
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+id+"?seat=0", nil)
+	req.Header.Set("X-Seat-Token", tokens[1].(string))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("get other seat: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// get seat view
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+id+"?seat=0", nil)
+	req.Header.Set("X-Seat-Token", tokens[0].(string))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte("opponentHandSize")) || bytes.Contains(rr.Body.Bytes(), []byte(`"hands"`)) {
+		t.Fatalf("get view: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// spectators cannot delete a session with seats
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/sessions/"+id, nil)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("delete without token: %d %s", rr.Code, rr.Body.String())
+	}
+
 	// delete session
 	rr = httptest.NewRecorder()
 	req = httptest.NewRequest(http.MethodDelete, "/sessions/"+id, nil)
+	req.Header.Set("X-Seat-Token", tokens[1].(string))
 	srv.ServeHTTP(rr, req)
 	if rr.Code != http.StatusNoContent || rr.Body.Len() != 0 {
 		t.Fatalf("delete: %d %s", rr.Code, rr.Body.String())
@@ -70,3 +160,309 @@ func TestServer_Flow(t *testing.T) {
 }
 
 func readAll(rc io.ReadCloser) []byte { b, _ := io.ReadAll(rc); return b }
+
+func TestServer_CreateWithRules(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(`{"seed":3,"rules":{"deckSize":20,"handSize":5}}`))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated || !bytes.Contains(rr.Body.Bytes(), []byte(`"deckSize":20`)) {
+		t.Fatalf("create with body: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, `/sessions?game=sixtysix&rules={"autoWin":true}`, nil)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated || !bytes.Contains(rr.Body.Bytes(), []byte(`"autoWin":true`)) {
+		t.Fatalf("create with query: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(`{"rules":{"deckSize":32}}`))
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid rules: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_LocalizedErrors(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", nil)
+	srv.ServeHTTP(rr, req)
+	var sess struct {
+		ID     string   `json:"id"`
+		Tokens []string `json:"tokens"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &sess); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+sess.ID, bytes.NewBufferString(`{"type":"closeStock"}`))
+	req.Header.Set("X-Seat-Token", sess.Tokens[1])
+	req.Header.Set("Accept-Language", "bg-BG,bg;q=0.9,en;q=0.5")
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden || rr.Header().Get("Content-Language") != "bg" ||
+		!bytes.Contains(rr.Body.Bytes(), []byte(`"error":"Не е ваш ред"`)) || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"notYourTurn"`)) {
+		t.Fatalf("localized error: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(`{"rules":{"handSize":20}}`))
+	req.Header.Set("Accept-Language", "bg")
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || !bytes.Contains(rr.Body.Bytes(), []byte(`"error":"Невалидни правила"`)) ||
+		!bytes.Contains(rr.Body.Bytes(), []byte(`"reason":"sixtysix: handSize must be between 3 and 11"`)) {
+		t.Fatalf("localized specific error: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/messages?lang=de", nil)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"locale":"de"`)) || !bytes.Contains(rr.Body.Bytes(), []byte("outcome.lastTrick")) {
+		t.Fatalf("messages: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_History(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix&seed=9", nil)
+	srv.ServeHTTP(rr, req)
+	var sess struct {
+		ID     string   `json:"id"`
+		Tokens []string `json:"tokens"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &sess); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/sessions/"+sess.ID, bytes.NewBufferString(`{"type":"closeStock"}`))
+	req.Header.Set("X-Seat-Token", sess.Tokens[0])
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("apply: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// the seed stays hidden while the deal is in progress
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+sess.ID+"/history", nil)
+	srv.ServeHTTP(rr, req)
+	var body struct {
+		History []engine.Record `json:"history"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("history: %d %s", rr.Code, rr.Body.String())
+	}
+	if len(body.History) != 2 || body.History[0].Seed != 0 || body.History[1].Action.Type != sixtysix.ActionCloseStock || body.History[1].Action.Actor != "0" {
+		t.Fatalf("history: %s", rr.Body.String())
+	}
+
+	// replay to the creation version, redacted for seat 0
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+sess.ID+"/history?version=1&seat=0", nil)
+	req.Header.Set("X-Seat-Token", sess.Tokens[0])
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"1"` || !bytes.Contains(rr.Body.Bytes(), []byte(`"closed":false`)) || bytes.Contains(rr.Body.Bytes(), []byte(`"hands"`)) {
+		t.Fatalf("replay: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+sess.ID+"/history?version=5", nil)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"versionNotFound"`)) {
+		t.Fatalf("unknown version: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/sessions/"+sess.ID+"/history?version=x", nil)
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"invalidVersion"`)) {
+		t.Fatalf("invalid version: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_Takeback(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+
+	create := func(body string) (id string, tokens []string) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(body)))
+		var sess struct {
+			ID     string   `json:"id"`
+			Tokens []string `json:"tokens"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &sess); err != nil {
+			t.Fatalf("json: %v", err)
+		}
+		return sess.ID, sess.Tokens
+	}
+	post := func(path, token, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("X-Seat-Token", token)
+		}
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	id, tokens := create(`{"seed":4}`)
+	if rr := post("/sessions/"+id, tokens[0], `{"type":"closeStock"}`); rr.Code != http.StatusOK {
+		t.Fatalf("apply: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/sessions/"+id+"/takeback", "", ``); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("spectator request: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/sessions/"+id+"/takeback", tokens[0], `{"actions":1}`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` || !bytes.Contains(rr.Body.Bytes(), []byte(`"takeback":{"seat":0,"actions":1}`)) {
+		t.Fatalf("request: %d %s %s", rr.Code, rr.Header().Get("ETag"), rr.Body.String())
+	}
+	ifMatch := func(path, token, etag, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Seat-Token", token)
+		req.Header.Set("If-Match", etag)
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+	// a move based on the earlier version would silently cancel a request
+	// its sender has not seen
+	if rr := ifMatch("/sessions/"+id, tokens[1], `"2"`, `{"type":"play","payload":{"card":0}}`); rr.Code != http.StatusConflict || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"conflict"`)) {
+		t.Fatalf("stale If-Match: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/sessions/"+id+"/takeback/accept", tokens[0], ``); rr.Code != http.StatusForbidden || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"ownTakeback"`)) {
+		t.Fatalf("own accept: %d %s", rr.Code, rr.Body.String())
+	}
+	// the request is replaced, so consent to the one seen at version 3 is void
+	if rr := post("/sessions/"+id+"/takeback", tokens[0], `{"actions":1}`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"4"` {
+		t.Fatalf("replace request: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := ifMatch("/sessions/"+id+"/takeback/accept", tokens[1], `"3"`, ``); rr.Code != http.StatusConflict {
+		t.Fatalf("accept of a replaced request: %d %s", rr.Code, rr.Body.String())
+	}
+	rr := ifMatch("/sessions/"+id+"/takeback/accept", tokens[1], `4`, ``)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"5"` || !bytes.Contains(rr.Body.Bytes(), []byte(`"closed":false`)) || bytes.Contains(rr.Body.Bytes(), []byte(`"takeback"`)) {
+		t.Fatalf("accept: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := post("/sessions/"+id+"/takeback", tokens[0], ``); rr.Code != http.StatusBadRequest || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"cannotUndo"`)) {
+		t.Fatalf("nothing to undo: %d %s", rr.Code, rr.Body.String())
+	}
+
+	id, tokens = create(`{"seed":4,"rated":true}`)
+	post("/sessions/"+id, tokens[0], `{"type":"closeStock"}`)
+	if rr := post("/sessions/"+id+"/takeback", tokens[0], ``); rr.Code != http.StatusForbidden || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"rated"`)) {
+		t.Fatalf("rated: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_Fork(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+	ctx := context.Background()
+
+	s, _ := e.CreateSession(ctx, "sixtysix", 8)
+	fork := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions/"+s.ID+"/fork"+query, nil))
+		return rr
+	}
+	if rr := fork("?version=1"); rr.Code != http.StatusConflict || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"notFinished"`)) {
+		t.Fatalf("fork in progress: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// play the deal out
+	for i := 0; !s.Finished && i < 100; i++ {
+		actor := strconv.Itoa(sixtysix.Game{}.ToMove(s.State))
+		actions, _ := e.LegalActions(ctx, s.ID, actor)
+		a := actions[0]
+		a.Actor = actor
+		var err error
+		if s, err = e.ApplyAction(ctx, s.ID, a); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+	}
+
+	rr := fork("?version=2")
+	var f engine.Session
+	if err := json.Unmarshal(rr.Body.Bytes(), &f); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("fork: %d %s", rr.Code, rr.Body.String())
+	}
+	if f.Parent != s.ID || f.ParentVersion != 2 || f.Version != 2 || f.Finished || len(f.Tokens) != 2 {
+		t.Fatalf("fork: %s", rr.Body.String())
+	}
+	if rr := fork("?version=0"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid version: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := fork("?version=999"); rr.Code != http.StatusNotFound {
+		t.Fatalf("unknown version: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_CreateFromState(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	srv := api.New(e)
+
+	puzzle := `{"state":{
+		"current": 0,
+		"hands": [["A♥", "10♠", "K♣"], ["10♥", "A♠", "Q♣"]],
+		"trumpSuit": 2, "trumpCard": "J♥",
+		"scores": [40, 42], "tricks": [4, 5],
+		"won": [
+			["A♣", "9♣", "10♣", "J♣", "A♦", "10♦", "K♦", "Q♦"],
+			["J♦", "9♦", "K♥", "Q♥", "J♥", "9♥", "K♠", "Q♠", "J♠", "9♠"]
+		]
+	}}`
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(puzzle)))
+	var sess struct {
+		ID    string `json:"id"`
+		State struct {
+			Hands [][]int `json:"hands"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &sess); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("create from state: %d %s", rr.Code, rr.Body.String())
+	}
+	if len(sess.State.Hands[0]) != 3 || sess.State.Hands[0][1] != int(sixtysix.NewCard(sixtysix.Hearts, sixtysix.Ace)) {
+		t.Fatalf("hands: %v", sess.State.Hands)
+	}
+
+	// the starting position stays hidden in the history while in play
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/sessions/"+sess.ID+"/history", nil))
+	if rr.Code != http.StatusOK || bytes.Contains(rr.Body.Bytes(), []byte(`"state"`)) {
+		t.Fatalf("history: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix", bytes.NewBufferString(`{"state":{"hands":[["A♥","A♥"],[]]}}`)))
+	if rr.Code != http.StatusBadRequest || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"invalidState"`)) {
+		t.Fatalf("invalid state: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// a state brings its own deal and rules, and is never rated
+	for _, tc := range []struct{ query, body string }{
+		{"&rated=true", `{"state":{}}`},
+		{"", `{"state":{},"seed":4}`},
+		{"", `{"state":{},"rules":{"target":33}}`},
+	} {
+		rr = httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions?game=sixtysix"+tc.query, bytes.NewBufferString(tc.body)))
+		if rr.Code != http.StatusBadRequest || !bytes.Contains(rr.Body.Bytes(), []byte(`"code":"stateWithOptions"`)) {
+			t.Fatalf("%s %s: %d %s", tc.query, tc.body, rr.Code, rr.Body.String())
+		}
+	}
+}
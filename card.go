@@ -0,0 +1,124 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Card is a playing card in the wire encoding suit*100 + rank value, e.g. 211
+// for the ace of hearts. It marshals to JSON as that int and unmarshals from
+// either the int or the string notation accepted by ParseCard.
+type Card int
+
+// Rank values. The value of a rank is its card points, except for the eight
+// and seven of the 32-card deck, which score nothing.
+const (
+	Ace   = 11
+	Ten   = 10
+	King  = 4
+	Queen = 3
+	Jack  = 2
+	Nine  = 0
+	Eight = 8
+	Seven = 7
+)
+
+var (
+	suitSymbols = []string{"♣", "♦", "♥", "♠"}
+	suitLetters = []string{"C", "D", "H", "S"}
+	rankSymbols = map[int]string{Ace: "A", Ten: "10", King: "K", Queen: "Q", Jack: "J", Nine: "9", Eight: "8", Seven: "7"}
+)
+
+// NewCard returns the card of the given suit (Clubs..Spades) and rank value.
+func NewCard(suit, rank int) Card { return Card(card(suit, rank)) }
+
+// Suit returns Clubs, Diamonds, Hearts or Spades.
+func (c Card) Suit() int { return cardSuit(int(c)) }
+
+// Rank returns the rank value, one of Ace through Seven.
+func (c Card) Rank() int { return cardVal(int(c)) }
+
+// Points returns what the card scores when captured.
+func (c Card) Points() int { return trickPoints(int(c)) }
+
+// Valid reports whether c is a card of the 32-card deck (which includes the
+// smaller decks).
+func (c Card) Valid() bool {
+	_, ok := rankSymbols[c.Rank()]
+	return c >= 0 && c.Suit() < len(suits) && ok
+}
+
+// String returns the rank and suit symbol, e.g. "A♥" or "10♠".
+func (c Card) String() string {
+	if !c.Valid() {
+		return "Card(" + strconv.Itoa(int(c)) + ")"
+	}
+	return rankSymbols[c.Rank()] + suitSymbols[c.Suit()]
+}
+
+// ParseCard parses a rank (A, K, Q, J, 10 or T, 9, 8, 7) followed by a suit
+// letter (C, D, H, S) or symbol (♣, ♦, ♥, ♠), case-insensitively: "A♥",
+// "9S", "t♠". A bare number is read as the int encoding.
+func ParseCard(s string) (Card, error) {
+	in := strings.ToUpper(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(in); err == nil {
+		if c := Card(n); c.Valid() {
+			return c, nil
+		}
+		return 0, ErrInvalidCard.Errorf("sixtysix: invalid card %q", s).With("card", s)
+	}
+	for suit := range suits {
+		for _, sym := range []string{suitLetters[suit], suitSymbols[suit]} {
+			r, ok := strings.CutSuffix(in, sym)
+			if !ok {
+				continue
+			}
+			if r == "T" {
+				r = "10"
+			}
+			for rank, rs := range rankSymbols {
+				if r == rs {
+					return NewCard(suit, rank), nil
+				}
+			}
+		}
+	}
+	return 0, ErrInvalidCard.Errorf("sixtysix: invalid card %q", s).With("card", s)
+}
+
+// UnmarshalJSON accepts the int encoding or a string for ParseCard.
+func (c *Card) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := ParseCard(s)
+		if err != nil {
+			return err
+		}
+		*c = v
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil {
+		return ErrInvalidCard.Errorf("sixtysix: invalid card %s", b)
+	}
+	*c = Card(n)
+	return nil
+}
+
+// getCard reads a card payload field given as its int encoding, a string in
+// card notation or a Card. It fails with ErrMissingCard, or ErrInvalidCard for
+// notation it cannot parse.
+func getCard(m map[string]any, k string) (int, error) {
+	switch v := m[k].(type) {
+	case string:
+		c, err := ParseCard(v)
+		return int(c), err
+	case Card:
+		return int(v), nil
+	}
+	if c, ok := getInt(m, k); ok {
+		return c, nil
+	}
+	return 0, ErrMissingCard
+}
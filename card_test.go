@@ -0,0 +1,73 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+func TestCardNotation(t *testing.T) {
+	c := NewCard(Hearts, Ace)
+	if int(c) != 211 || c.Suit() != Hearts || c.Rank() != Ace || c.Points() != 11 || c.String() != "A♥" {
+		t.Fatalf("unexpected ace of hearts: %d %s", int(c), c)
+	}
+	if NewCard(Spades, Ten).String() != "10♠" || NewCard(Clubs, Nine).String() != "9♣" || Card(-1).String() != "Card(-1)" {
+		t.Fatalf("unexpected strings")
+	}
+	for in, want := range map[string]Card{
+		"A♥":  NewCard(Hearts, Ace),
+		"9S":  NewCard(Spades, Nine),
+		"jd":  NewCard(Diamonds, Jack),
+		"10C": NewCard(Clubs, Ten),
+		"T♠":  NewCard(Spades, Ten),
+		"7h":  NewCard(Hearts, Seven),
+		"304": NewCard(Spades, King),
+	} {
+		got, err := ParseCard(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseCard(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1H", "AX", "2011", "K"} {
+		if _, err := ParseCard(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestCardJSON(t *testing.T) {
+	var cs []Card
+	if err := json.Unmarshal([]byte(`[211, "Q♣", "10d"]`), &cs); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if cs[0] != NewCard(Hearts, Ace) || cs[1] != NewCard(Clubs, Queen) || cs[2] != NewCard(Diamonds, Ten) {
+		t.Fatalf("unexpected cards: %v", cs)
+	}
+	b, _ := json.Marshal(cs)
+	if string(b) != "[211,3,110]" {
+		t.Fatalf("expected cards to marshal as ints, got %s", b)
+	}
+	if err := json.Unmarshal([]byte(`"XX"`), &cs[0]); err == nil {
+		t.Fatalf("expected invalid notation to be rejected")
+	}
+}
+
+func TestPlayAcceptsCardNotation(t *testing.T) {
+	g := Game{}
+	st := g.InitialState(42).(State)
+	c := Card(st.Hands[st.Current][0])
+	a := engine.Action{Type: ActionPlay, Payload: map[string]any{"card": c.String()}}
+	if err := g.Validate(st, a); err != nil {
+		t.Fatalf("validate %s: %v", c, err)
+	}
+	ns, err := g.Apply(st, a)
+	if err != nil || !slices.Equal(ns.(State).Trick, []int{int(c)}) {
+		t.Fatalf("apply %s: %v", c, err)
+	}
+	if err := g.Validate(st, engine.Action{Type: ActionPlay, Payload: map[string]any{"card": "nope"}}); !errors.Is(err, ErrInvalidCard) {
+		t.Fatalf("expected bad notation to be rejected, got %v", err)
+	}
+}
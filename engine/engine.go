@@ -3,19 +3,28 @@ package engine
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 )
 
 // Action is a generic instruction sent by a client/actor.
 type Action struct {
-	Type    string         `json:"type"`
+	Type string `json:"type"`
+	// Actor is the authenticated seat (decimal index) submitting the action.
+	// It is set by the transport after checking the seat token; when empty the
+	// engine does not check whose turn it is.
 	Actor   string         `json:"actor,omitempty"`
 	Payload map[string]any `json:"payload,omitempty"`
 	// Client-provided idempotency key to safely retry requests
 	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// ExpectedVersion, when non-zero, makes the action fail with ErrConflict
+	// unless the session is still at this version.
+	ExpectedVersion int `json:"expectedVersion,omitempty"`
 }
 
 // Game defines the logic for a particular game.
@@ -31,6 +40,58 @@ type Game interface {
 	Apply(state any, action Action) (any, error)
 }
 
+// Configurable is an optional interface for games whose initial state depends
+// on client-supplied options, such as a rule variant.
+type Configurable interface {
+	// InitialStateWith is InitialState with game-specific JSON options.
+	InitialStateWith(seed int64, options json.RawMessage) (any, error)
+}
+
+// OptionsReporter is an optional interface for Configurable games whose
+// defaults may change, e.g. with the game's configuration. Options returns
+// options that rebuild state from its seed whatever the defaults; the create
+// record keeps them so that replays stay faithful.
+type OptionsReporter interface {
+	Options(state any) (json.RawMessage, error)
+}
+
+// Viewer is an optional interface for games with hidden information. ViewFor
+// returns the part of state a given seat is allowed to see.
+type Viewer interface {
+	ViewFor(state any, seat int) any
+}
+
+// ActionLister is an optional interface for games that can enumerate the
+// actions valid in a state, e.g. to grey out unplayable cards or drive bots.
+type ActionLister interface {
+	// LegalActions returns every action Validate would accept for the seat
+	// to move.
+	LegalActions(state any) []Action
+}
+
+// StateLoader is an optional interface for games that can start from a state
+// supplied by a client, e.g. an endgame puzzle.
+type StateLoader interface {
+	// LoadState decodes a JSON state and rejects inconsistent ones.
+	LoadState(raw json.RawMessage) (any, error)
+}
+
+// Finisher is an optional interface for games with a terminal state. Finished
+// sessions are flagged and reject further actions with ErrGameOver.
+type Finisher interface {
+	Finished(state any) bool
+}
+
+// TurnBased is an optional interface for games played by a fixed number of
+// seats. The engine issues one token per seat and rejects actions from a seat
+// that is not to move.
+type TurnBased interface {
+	// Seats returns the number of seats at the table.
+	Seats(state any) int
+	// ToMove returns the seat expected to act next, or -1 if nobody is.
+	ToMove(state any) int
+}
+
 // Session represents a single instance of a game.
 type Session struct {
 	ID        string    `json:"id"`
@@ -39,21 +100,73 @@ type Session struct {
 	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// Finished reports that the game reached a terminal state.
+	Finished bool `json:"finished"`
+	// Tokens holds one secret per seat for TurnBased games. Transports must
+	// not reveal them beyond the session creator.
+	Tokens []string `json:"tokens,omitempty"`
+	// Rated sessions refuse takebacks.
+	Rated bool `json:"rated,omitempty"`
+	// Takeback is a request to undo actions awaiting another seat's answer.
+	// Requests and answers create versions; any applied action cancels it.
+	Takeback *Takeback `json:"takeback,omitempty"`
+	// Parent is the session this one was forked from at ParentVersion.
+	Parent        string `json:"parent,omitempty"`
+	ParentVersion int    `json:"parentVersion,omitempty"`
+}
+
+// SeatOf returns the seat that token authenticates.
+func (s Session) SeatOf(token string) (int, bool) {
+	if token == "" {
+		return -1, false
+	}
+	for i, t := range s.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return i, true
+		}
+	}
+	return -1, false
 }
 
 // Store abstracts persistence for sessions.
 type Store interface {
-	Create(ctx context.Context, s Session) error
+	// Create stores a new session together with its history so far, which
+	// must run from version 1 to s.Version.
+	Create(ctx context.Context, s Session, history []Record) error
 	Get(ctx context.Context, id string) (Session, bool, error)
-	Update(ctx context.Context, s Session) error
 	List(ctx context.Context, gameName string, offset, limit int) ([]Session, error)
 	Delete(ctx context.Context, id string) error
+	// LoadResult returns the session Commit saved for key, if still
+	// remembered. Stores may keep only the most recent keys per session.
+	LoadResult(ctx context.Context, id, key string) (Session, bool, error)
+	// Commit replaces a session only if its stored Version still equals
+	// expectedVersion, adds r, the record of s.Version, to its history and,
+	// if key is set, saves s as the result of that idempotency key, as one
+	// step: either all take effect or none does. A stale expectedVersion or a
+	// record that does not follow the last one yields ErrConflict. Committing
+	// a rewind record forgets the session's idempotency results, which
+	// describe the line of play it undid.
+	Commit(ctx context.Context, s Session, expectedVersion int, r Record, key string) error
+	// History returns every record of the session, oldest first.
+	History(ctx context.Context, id string) ([]Record, error)
 }
 
+// Errors returned by the engine. Games report their own *Error values from
+// Validate; anything else is treated as a plain validation failure.
 var (
-	ErrGameNotFound    = errors.New("engine: game not found")
-	ErrSessionNotFound = errors.New("engine: session not found")
-	ErrConflict        = errors.New("engine: conflict")
+	ErrGameNotFound    = NewError("gameNotFound", "engine: game not found")
+	ErrSessionNotFound = NewError("sessionNotFound", "engine: session not found")
+	ErrConflict        = NewError("conflict", "engine: conflict")
+	ErrUnauthorized    = NewError("unauthorized", "engine: invalid seat token")
+	ErrNotYourTurn     = NewError("notYourTurn", "engine: not your turn")
+	ErrGameOver        = NewError("gameOver", "engine: game over")
+	ErrNoOptions       = NewError("noOptions", "engine: game does not accept options")
+	ErrVersionNotFound = NewError("versionNotFound", "engine: version not found")
+	ErrRated           = NewError("rated", "engine: takebacks are not allowed in rated sessions")
+	ErrCannotUndo      = NewError("cannotUndo", "engine: not that many actions to undo")
+	ErrNoTakeback      = NewError("noTakeback", "engine: no takeback request to answer")
+	ErrOwnTakeback     = NewError("ownTakeback", "engine: cannot answer your own takeback request")
+	ErrNoCustomState   = NewError("noCustomState", "engine: game does not accept a custom state")
 )
 
 // Engine wires games with storage and provides a simple API to manipulate sessions.
@@ -94,28 +207,119 @@ func (e *Engine) Games() []string {
 
 // CreateSession creates a new session for the named game.
 func (e *Engine) CreateSession(ctx context.Context, gameName string, seed int64) (Session, error) {
-	e.mu.RLock()
-	g, ok := e.games[gameName]
-	e.mu.RUnlock()
-	if !ok {
-		return Session{}, ErrGameNotFound
+	return e.CreateSessionWithOptions(ctx, gameName, seed, nil)
+}
+
+// CreateSessionWithOptions creates a session whose initial state is built from
+// options by a Configurable game. Empty options behave like CreateSession.
+func (e *Engine) CreateSessionWithOptions(ctx context.Context, gameName string, seed int64, options json.RawMessage) (Session, error) {
+	return e.createSession(ctx, gameName, Record{Seed: seed, Options: options}, false)
+}
+
+// CreateRatedSession is CreateSessionWithOptions for a Rated session, in which
+// actions cannot be taken back.
+func (e *Engine) CreateRatedSession(ctx context.Context, gameName string, seed int64, options json.RawMessage) (Session, error) {
+	return e.createSession(ctx, gameName, Record{Seed: seed, Options: options}, true)
+}
+
+// CreateSessionFromState creates a session starting from an explicit state,
+// which a StateLoader game decodes and validates; other games return
+// ErrNoCustomState. The state is kept in the create record for replays.
+func (e *Engine) CreateSessionFromState(ctx context.Context, gameName string, state json.RawMessage) (Session, error) {
+	return e.createSession(ctx, gameName, Record{State: state}, false)
+}
+
+// createSession stores a new session starting as described by rec, which
+// becomes its create record.
+func (e *Engine) createSession(ctx context.Context, gameName string, rec Record, rated bool) (Session, error) {
+	g, err := e.game(gameName)
+	if err != nil {
+		return Session{}, err
+	}
+	state, err := startState(g, rec)
+	if err != nil {
+		return Session{}, err
+	}
+	if len(rec.State) > 0 {
+		// keep the state as the game encodes it
+		if rec.State, err = json.Marshal(state); err != nil {
+			return Session{}, err
+		}
+	} else if r, ok := g.(OptionsReporter); ok {
+		if rec.Options, err = r.Options(state); err != nil {
+			return Session{}, err
+		}
 	}
 	id := randomID()
 	now := time.Now().UTC()
 	s := Session{
 		ID:        id,
 		GameName:  gameName,
-		State:     g.InitialState(seed),
+		State:     state,
 		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Rated:     rated,
 	}
-	if err := e.store.Create(ctx, s); err != nil {
+	s.Finished = finished(g, s.State)
+	s.Tokens = tokens(g, s.State)
+	rec.Version, rec.Kind, rec.At = 1, RecordCreate, now
+	if err := e.store.Create(ctx, s, []Record{rec}); err != nil {
 		return Session{}, err
 	}
 	return s, nil
 }
 
+// tokens returns a new secret per seat of state for TurnBased games.
+func tokens(g Game, state any) []string {
+	tb, ok := g.(TurnBased)
+	if !ok {
+		return nil
+	}
+	out := make([]string, tb.Seats(state))
+	for i := range out {
+		out[i] = randomID()
+	}
+	return out
+}
+
+// startState builds the state a create record describes: its State for a
+// StateLoader, otherwise the initial state for its seed and options.
+func startState(g Game, rec Record) (any, error) {
+	if len(rec.State) == 0 {
+		return initialState(g, rec.Seed, rec.Options)
+	}
+	l, ok := g.(StateLoader)
+	if !ok {
+		return nil, ErrNoCustomState
+	}
+	return l.LoadState(rec.State)
+}
+
+// initialState builds the starting state from a seed and, for Configurable
+// games, options.
+func initialState(g Game, seed int64, options json.RawMessage) (any, error) {
+	if len(options) == 0 {
+		return g.InitialState(seed), nil
+	}
+	c, ok := g.(Configurable)
+	if !ok {
+		return nil, ErrNoOptions
+	}
+	return c.InitialStateWith(seed, options)
+}
+
+// game returns the registered game with the given name.
+func (e *Engine) game(name string) (Game, error) {
+	e.mu.RLock()
+	g, ok := e.games[name]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return g, nil
+}
+
 // GetSession returns a session by id.
 func (e *Engine) GetSession(ctx context.Context, id string) (Session, error) {
 	s, ok, err := e.store.Get(ctx, id)
@@ -128,8 +332,42 @@ func (e *Engine) GetSession(ctx context.Context, id string) (Session, error) {
 	return s, nil
 }
 
+// maxAttempts bounds how often ApplyAction retries after losing a race with a
+// concurrent update of the same session.
+const maxAttempts = 3
+
 // ApplyAction validates and applies an action to the session state.
+// When action.Actor is set and the game is TurnBased, the actor must be the
+// seat to move. An action whose IdempotencyKey was already applied returns the
+// session it originally produced instead of being applied again.
+//
+// Updates are compare-and-swap on Session.Version. If action.ExpectedVersion
+// is set and does not match, ErrConflict is returned; otherwise a lost race is
+// retried against the fresh state before giving up with ErrConflict. A race
+// lost to the same idempotency key, e.g. a client retrying while its first
+// request is still in flight, is always retried, and yields that request's
+// session.
 func (e *Engine) ApplyAction(ctx context.Context, id string, action Action) (Session, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		s, err := e.applyOnce(ctx, id, action)
+		if errors.Is(err, ErrConflict) && (action.ExpectedVersion == 0 || action.IdempotencyKey != "") {
+			continue
+		}
+		return s, err
+	}
+	return Session{}, ErrConflict
+}
+
+func (e *Engine) applyOnce(ctx context.Context, id string, action Action) (Session, error) {
+	if action.IdempotencyKey != "" {
+		prev, ok, err := e.store.LoadResult(ctx, id, action.IdempotencyKey)
+		if err != nil {
+			return Session{}, err
+		}
+		if ok {
+			return prev, nil
+		}
+	}
 	s, ok, err := e.store.Get(ctx, id)
 	if err != nil {
 		return Session{}, err
@@ -137,12 +375,21 @@ func (e *Engine) ApplyAction(ctx context.Context, id string, action Action) (Ses
 	if !ok {
 		return Session{}, ErrSessionNotFound
 	}
+	if action.ExpectedVersion != 0 && action.ExpectedVersion != s.Version {
+		return Session{}, ErrConflict
+	}
+	if s.Finished {
+		return Session{}, ErrGameOver
+	}
 	e.mu.RLock()
 	g, ok := e.games[s.GameName]
 	e.mu.RUnlock()
 	if !ok {
 		return Session{}, ErrGameNotFound
 	}
+	if err := checkTurn(g, s.State, action.Actor); err != nil {
+		return Session{}, err
+	}
 	if err := g.Validate(s.State, action); err != nil {
 		return Session{}, err
 	}
@@ -150,15 +397,59 @@ func (e *Engine) ApplyAction(ctx context.Context, id string, action Action) (Ses
 	if err != nil {
 		return Session{}, err
 	}
+	prev := s.Version
 	s.State = newState
+	s.Finished = finished(g, newState)
 	s.Version++
 	s.UpdatedAt = time.Now().UTC()
-	if err := e.store.Update(ctx, s); err != nil {
+	s.Takeback = nil
+	rec := Record{Version: s.Version, Kind: RecordAction, At: s.UpdatedAt, Action: logged(action)}
+	if err := e.store.Commit(ctx, s, prev, rec, action.IdempotencyKey); err != nil {
 		return Session{}, err
 	}
 	return s, nil
 }
 
+// LegalActions returns the actions currently valid in a session. If actor is
+// set and the game is TurnBased, the list is empty unless actor is the seat to
+// move. Games that do not implement ActionLister yield an empty list.
+func (e *Engine) LegalActions(ctx context.Context, id, actor string) ([]Action, error) {
+	s, err := e.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	g, ok := e.games[s.GameName]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	l, ok := g.(ActionLister)
+	if !ok || checkTurn(g, s.State, actor) != nil {
+		return []Action{}, nil
+	}
+	out := l.LegalActions(s.State)
+	if out == nil {
+		out = []Action{}
+	}
+	return out, nil
+}
+
+// View returns a copy of s whose state is redacted for seat when the game
+// implements Viewer. Sessions of other games are returned unchanged.
+func (e *Engine) View(s Session, seat int) Session {
+	e.mu.RLock()
+	g, ok := e.games[s.GameName]
+	e.mu.RUnlock()
+	if !ok {
+		return s
+	}
+	if v, ok := g.(Viewer); ok {
+		s.State = v.ViewFor(s.State, seat)
+	}
+	return s
+}
+
 // ListSessions returns sessions for a given game.
 func (e *Engine) ListSessions(ctx context.Context, gameName string, offset, limit int) ([]Session, error) {
 	return e.store.List(ctx, gameName, offset, limit)
@@ -168,6 +459,27 @@ func (e *Engine) DeleteSession(ctx context.Context, id string) error {
 	return e.store.Delete(ctx, id)
 }
 
+func finished(g Game, state any) bool {
+	f, ok := g.(Finisher)
+	return ok && f.Finished(state)
+}
+
+func checkTurn(g Game, state any, actor string) error {
+	tb, ok := g.(TurnBased)
+	if !ok || actor == "" {
+		return nil
+	}
+	toMove := tb.ToMove(state)
+	if toMove < 0 {
+		return nil // let the game report why nobody may act
+	}
+	seat, err := strconv.Atoi(actor)
+	if err != nil || seat != toMove {
+		return ErrNotYourTurn
+	}
+	return nil
+}
+
 func randomID() string {
 	var b [16]byte
 	_, _ = rand.Read(b[:])
@@ -2,6 +2,11 @@ package engine_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"go.rumenx.com/sixtysix"
@@ -34,6 +39,21 @@ func TestEngine_CreateGetApplyListDelete(t *testing.T) {
 		t.Fatalf("apply closeStock: %v", err)
 	}
 
+	// seat tokens
+	if len(s.Tokens) != 2 {
+		t.Fatalf("expected two seat tokens, got %d", len(s.Tokens))
+	}
+	if seat, ok := s.SeatOf(s.Tokens[1]); !ok || seat != 1 {
+		t.Fatalf("seat of token: seat=%d ok=%v", seat, ok)
+	}
+	if _, ok := s.SeatOf("bogus"); ok {
+		t.Fatal("expected a bogus token to match no seat")
+	}
+	// after closing the stock seat 0 still leads, so seat 1 may not act
+	if _, err := e.ApplyAction(context.Background(), s.ID, engine.Action{Type: sixtysix.ActionCloseStock, Actor: "1"}); !errors.Is(err, engine.ErrNotYourTurn) {
+		t.Fatalf("expected ErrNotYourTurn, got %v", err)
+	}
+
 	// list
 	list, err := e.ListSessions(context.Background(), "sixtysix", 0, 10)
 	if err != nil || len(list) == 0 {
@@ -45,3 +65,196 @@ func TestEngine_CreateGetApplyListDelete(t *testing.T) {
 		t.Fatalf("delete: %v", err)
 	}
 }
+
+func TestEngine_IdempotencyKey(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, err := e.CreateSession(context.Background(), "sixtysix", 3)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	st := s.State.(sixtysix.State)
+	play := engine.Action{Type: sixtysix.ActionPlay, Payload: map[string]any{"card": st.Hands[0][0]}, IdempotencyKey: "k1"}
+	first, err := e.ApplyAction(context.Background(), s.ID, play)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	// the card has left the hand, so a real second apply would fail
+	again, err := e.ApplyAction(context.Background(), s.ID, play)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if again.Version != first.Version || again.Version != 2 {
+		t.Fatalf("expected original result, got version %d (first %d)", again.Version, first.Version)
+	}
+}
+
+// slowStore holds its first Commit until released, before or after the
+// commit takes effect, to let another request overtake it.
+type slowStore struct {
+	*store.Memory
+	before           bool
+	once             sync.Once
+	entered, release chan struct{}
+}
+
+func (s *slowStore) Commit(ctx context.Context, sess engine.Session, expectedVersion int, r engine.Record, key string) error {
+	first := false
+	s.once.Do(func() { first = true })
+	hold := func() {
+		close(s.entered)
+		<-s.release
+	}
+	if first && s.before {
+		hold()
+	}
+	err := s.Memory.Commit(ctx, sess, expectedVersion, r, key)
+	if first && !s.before {
+		hold()
+	}
+	return err
+}
+
+func TestEngine_IdempotencyKeyInFlight(t *testing.T) {
+	ctx := context.Background()
+	for _, before := range []bool{false, true} {
+		slow := &slowStore{Memory: store.NewMemory(), before: before, entered: make(chan struct{}), release: make(chan struct{})}
+		e := engine.New(slow)
+		e.Register(sixtysix.Game{})
+		s, _ := e.CreateSession(ctx, "sixtysix", 3)
+		st := s.State.(sixtysix.State)
+		play := engine.Action{Type: sixtysix.ActionPlay, Payload: map[string]any{"card": st.Hands[0][0]}, IdempotencyKey: "k1"}
+
+		done := make(chan engine.Session)
+		go func() {
+			first, err := e.ApplyAction(ctx, s.ID, play)
+			if err != nil {
+				t.Errorf("apply: %v", err)
+			}
+			done <- first
+		}()
+		<-slow.entered
+		// the client gives up waiting and retries
+		retry, err := e.ApplyAction(ctx, s.ID, play)
+		close(slow.release)
+		first := <-done
+		if err != nil || retry.Version != 2 || first.Version != 2 {
+			t.Fatalf("before=%v: retry %v version %d, first version %d", before, err, retry.Version, first.Version)
+		}
+		if got, _ := e.GetSession(ctx, s.ID); got.Version != 2 {
+			t.Fatalf("before=%v: action applied twice, version %d", before, got.Version)
+		}
+	}
+}
+
+func TestEngine_ExpectedVersionConflict(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateSession(context.Background(), "sixtysix", 4)
+	closeStock := engine.Action{Type: sixtysix.ActionCloseStock, ExpectedVersion: 2}
+	if _, err := e.ApplyAction(context.Background(), s.ID, closeStock); !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	closeStock.ExpectedVersion = 1
+	if got, err := e.ApplyAction(context.Background(), s.ID, closeStock); err != nil || got.Version != 2 {
+		t.Fatalf("apply at expected version: %v", err)
+	}
+}
+
+func TestEngine_ConcurrentActionsDoNotLoseUpdates(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateSession(context.Background(), "sixtysix", 8)
+	st := s.State.(sixtysix.State)
+	var wg sync.WaitGroup
+	var applied atomic.Int32
+	for _, c := range st.Hands[0] {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			if _, err := e.ApplyAction(context.Background(), s.ID, engine.Action{Type: sixtysix.ActionPlay, Payload: map[string]any{"card": c}}); err == nil {
+				applied.Add(1)
+			}
+		}(c)
+	}
+	wg.Wait()
+	// only one lead can be played; every other attempt must fail rather than overwrite it
+	got, _ := e.GetSession(context.Background(), s.ID)
+	if applied.Load() != 1 || got.Version != 2 {
+		t.Fatalf("expected exactly one applied lead, got %d (version %d)", applied.Load(), got.Version)
+	}
+}
+
+func TestEngine_FinishedSessionRejectsActions(t *testing.T) {
+	mem := store.NewMemory()
+	e := engine.New(mem)
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateSession(context.Background(), "sixtysix", 0)
+	if s.Finished {
+		t.Fatalf("new session should not be finished")
+	}
+	st := s.State.(sixtysix.State)
+	st.DealOver = true
+	s.State = st
+	s.Finished = true
+	s.Version++
+	rec := engine.Record{Version: s.Version, Kind: engine.RecordAction, Action: &engine.Action{Type: sixtysix.ActionCloseStock}}
+	if err := mem.Commit(context.Background(), s, 1, rec, ""); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := e.ApplyAction(context.Background(), s.ID, engine.Action{Type: sixtysix.ActionCloseStock}); !errors.Is(err, engine.ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+}
+
+func TestEngine_CreateSessionWithOptions(t *testing.T) {
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, err := e.CreateSessionWithOptions(context.Background(), "sixtysix", 1, []byte(`{"target":33}`))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if r := s.State.(sixtysix.State).Rules; r == nil || r.Target != 33 {
+		t.Fatalf("rules not applied: %+v", r)
+	}
+	three, err := e.CreateSessionWithOptions(context.Background(), "sixtysix", 1, []byte(`{"players":3}`))
+	if err != nil || len(three.Tokens) != 3 {
+		t.Fatalf("expected a token per seat of a three-player deal: %v %d", err, len(three.Tokens))
+	}
+	if _, err := e.CreateSessionWithOptions(context.Background(), "sixtysix", 1, []byte(`{"deckSize":7}`)); err == nil {
+		t.Fatalf("expected invalid rules to be rejected")
+	}
+}
+
+func TestEngine_CreateSessionFromState(t *testing.T) {
+	ctx := context.Background()
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	e.Register(sixtysix.Partnership{})
+	src, _ := e.CreateSession(ctx, "sixtysix", 2)
+	played := play(t, e, src, 3)
+	raw, _ := json.Marshal(played[2].State)
+
+	s, err := e.CreateSessionFromState(ctx, "sixtysix", raw)
+	if err != nil {
+		t.Fatalf("create from state: %v", err)
+	}
+	if s.Version != 1 || len(s.Tokens) != 2 {
+		t.Fatalf("unexpected session: %+v", s)
+	}
+	// the custom start is replayed from the create record
+	play(t, e, s, 1)
+	if _, err := e.Undo(ctx, s.ID, 1); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if r, err := e.Replay(ctx, s.ID, 1); err != nil || !reflect.DeepEqual(r.State, s.State) {
+		t.Fatalf("replay: %v", err)
+	}
+
+	if _, err := e.CreateSessionFromState(ctx, "sixtysix", []byte(`{"hands":[[],[]]}`)); !errors.Is(err, sixtysix.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	if _, err := e.CreateSessionFromState(ctx, "sixtysix-partnership", raw); !errors.Is(err, engine.ErrNoCustomState) {
+		t.Fatalf("expected ErrNoCustomState, got %v", err)
+	}
+}
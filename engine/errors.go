@@ -0,0 +1,52 @@
+package engine
+
+import "fmt"
+
+// Error is an error with a stable, machine-readable Code that clients can
+// switch on and localize; Message is the English text and Details carries
+// specifics such as the offending card. Two Errors match under errors.Is when
+// their codes are equal, so a sentinel matches every copy made from it.
+type Error struct {
+	Code    string         `json:"code"`
+	Message string         `json:"error"`
+	Details map[string]any `json:"details,omitempty"`
+
+	// specific is set by Errorf: Message says more than the code does.
+	specific bool
+}
+
+// NewError returns an Error with the given code and message.
+func NewError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Is reports whether target is an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// With returns a copy of e with one more detail.
+func (e *Error) With(key string, value any) *Error {
+	c := *e
+	c.Details = make(map[string]any, len(e.Details)+1)
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// Errorf returns a copy of e with a formatted message.
+func (e *Error) Errorf(format string, args ...any) *Error {
+	c := *e
+	c.Message = fmt.Sprintf(format, args...)
+	c.specific = true
+	return &c
+}
+
+// Specific reports whether e's message was formatted with Errorf and so
+// carries more than a translation of its code would.
+func (e *Error) Specific() bool { return e.specific }
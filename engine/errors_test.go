@@ -0,0 +1,26 @@
+package engine_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+func TestError_IsByCode(t *testing.T) {
+	base := engine.NewError("mustFollowSuit", "must follow suit")
+	withCard := base.With("card", 211)
+	if !errors.Is(withCard, base) || !errors.Is(fmt.Errorf("wrapped: %w", withCard), base) {
+		t.Fatalf("expected copies to match the sentinel")
+	}
+	if errors.Is(withCard, engine.ErrConflict) {
+		t.Fatalf("different codes must not match")
+	}
+	if base.Details != nil || withCard.Details["card"] != 211 {
+		t.Fatalf("With must not modify the sentinel: %+v %+v", base, withCard)
+	}
+	if e := engine.ErrGameOver.Errorf("engine: game %d over", 3); e.Error() != "engine: game 3 over" || !errors.Is(e, engine.ErrGameOver) || engine.ErrGameOver.Message != "engine: game over" {
+		t.Fatalf("unexpected Errorf result: %v", e)
+	}
+}
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Record kinds.
+const (
+	RecordCreate   = "create"   // the session was created (Version 1)
+	RecordAction   = "action"   // Action produced Version
+	RecordRewind   = "rewind"   // Version restored the state of version To
+	RecordTakeback = "takeback" // Version opened Takeback, or closed it if nil
+)
+
+// Record is one entry of a session's append-only history. Replaying the
+// create record's Seed and Options through InitialState, then every action in
+// order through Apply, rebuilds the session's state.
+type Record struct {
+	Version int       `json:"version"`
+	Kind    string    `json:"kind"`
+	At      time.Time `json:"at"`
+	// Action as applied, including the Actor; action records only.
+	Action *Action `json:"action,omitempty"`
+	// Seed and Options of the initial state (as reported by an
+	// OptionsReporter game), or the State a session was started from; create
+	// records only.
+	Seed    int64           `json:"seed,omitempty"`
+	Options json.RawMessage `json:"options,omitempty"`
+	State   json.RawMessage `json:"state,omitempty"`
+	// To is the earlier version whose state was restored; rewind records
+	// only.
+	To int `json:"to,omitempty"`
+	// Takeback is the request opened; takeback records only.
+	Takeback *Takeback `json:"takeback,omitempty"`
+}
+
+// History returns the records of a session, oldest first.
+func (e *Engine) History(ctx context.Context, id string) ([]Record, error) {
+	if _, err := e.GetSession(ctx, id); err != nil {
+		return nil, err
+	}
+	return e.store.History(ctx, id)
+}
+
+// Replay rebuilds a session as it was at toVersion by re-running InitialState
+// and Apply over its history; toVersion <= 0 replays to the current version.
+// Unknown versions yield ErrVersionNotFound.
+func (e *Engine) Replay(ctx context.Context, id string, toVersion int) (Session, error) {
+	s, err := e.GetSession(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	if toVersion <= 0 {
+		toVersion = s.Version
+	}
+	if toVersion > s.Version {
+		return Session{}, ErrVersionNotFound
+	}
+	g, err := e.game(s.GameName)
+	if err != nil {
+		return Session{}, err
+	}
+	recs, err := e.store.History(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	n := 0
+	for n < len(recs) && recs[n].Version <= toVersion {
+		n++
+	}
+	if n == 0 || recs[0].Kind != RecordCreate {
+		return Session{}, ErrVersionNotFound
+	}
+	state, err := Project(g, nil, recs[:n])
+	if err != nil {
+		return Session{}, err
+	}
+	s.State = state
+	s.Version = toVersion
+	s.UpdatedAt = recs[n-1].At
+	s.Finished = finished(g, state)
+	return s, nil
+}
+
+// Fork creates an independent session from another at version (<= 0 for the
+// current one). The fork copies the history up to that version, so its state
+// is re-derived by replay and its versions continue from there; it gets new
+// seat tokens, is never Rated and records its Parent.
+func (e *Engine) Fork(ctx context.Context, id string, version int) (Session, error) {
+	past, err := e.Replay(ctx, id, version)
+	if err != nil {
+		return Session{}, err
+	}
+	g, err := e.game(past.GameName)
+	if err != nil {
+		return Session{}, err
+	}
+	recs, err := e.store.History(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	now := time.Now().UTC()
+	s := Session{
+		ID:            randomID(),
+		GameName:      past.GameName,
+		State:         past.State,
+		Version:       past.Version,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Finished:      past.Finished,
+		Tokens:        tokens(g, past.State),
+		Parent:        id,
+		ParentVersion: past.Version,
+	}
+	n := 0
+	for n < len(recs) && recs[n].Version <= s.Version {
+		n++
+	}
+	if err := e.store.Create(ctx, s, recs[:n]); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+// Project folds records, oldest first, into a state of g. A create record
+// starts over from its State or from InitialState with its seed and options;
+// action records are applied to the state so far, which starts as state (e.g.
+// a snapshot taken before the first record); rewind records go back to the
+// state of an earlier record, which must be among records or be the starting
+// state; takeback records leave the state as it is.
+func Project(g Game, state any, records []Record) (any, error) {
+	start := state
+	for i, r := range records {
+		var err error
+		switch r.Kind {
+		case RecordCreate:
+			state, err = startState(g, r)
+		case RecordAction:
+			state, err = g.Apply(state, *r.Action)
+		case RecordRewind:
+			if r.To == records[0].Version-1 {
+				state = start
+				break
+			}
+			j := slices.IndexFunc(records[:i], func(p Record) bool { return p.Version == r.To })
+			if j < 0 {
+				return nil, ErrVersionNotFound
+			}
+			state, err = Project(g, start, records[:j+1])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// logged is the part of an action worth keeping in the history; idempotency
+// keys and version preconditions only matter to the request that sent them.
+func logged(a Action) *Action {
+	return &Action{Type: a.Type, Actor: a.Actor, Payload: a.Payload}
+}
@@ -0,0 +1,129 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/sixtysix"
+	"go.rumenx.com/sixtysix/engine"
+	"go.rumenx.com/sixtysix/store"
+)
+
+func TestEngine_HistoryAndReplay(t *testing.T) {
+	ctx := context.Background()
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, err := e.CreateSessionWithOptions(ctx, "sixtysix", 7, []byte(`{"target":33}`))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	states := map[int]any{s.Version: s.State}
+	for i := 0; i < 3; i++ {
+		actor := strconv.Itoa(sixtysix.Game{}.ToMove(s.State))
+		actions, err := e.LegalActions(ctx, s.ID, actor)
+		if err != nil || len(actions) == 0 {
+			t.Fatalf("legal actions: %v %d", err, len(actions))
+		}
+		a := actions[0]
+		a.Actor = actor
+		a.IdempotencyKey = "k" + strconv.Itoa(i)
+		if s, err = e.ApplyAction(ctx, s.ID, a); err != nil {
+			t.Fatalf("apply %+v: %v", a, err)
+		}
+		states[s.Version] = s.State
+	}
+
+	history, err := e.History(ctx, s.ID)
+	if err != nil || len(history) != 4 {
+		t.Fatalf("history: %v len=%d", err, len(history))
+	}
+	if h := history[0]; h.Kind != engine.RecordCreate || h.Version != 1 || h.Seed != 7 || !strings.Contains(string(h.Options), `"target":33`) {
+		t.Fatalf("create record: %+v", h)
+	}
+	for i, h := range history[1:] {
+		if h.Kind != engine.RecordAction || h.Version != i+2 || h.Action == nil || h.Action.IdempotencyKey != "" {
+			t.Fatalf("action record %d: %+v", i, h)
+		}
+	}
+
+	for v, want := range states {
+		got, err := e.Replay(ctx, s.ID, v)
+		if err != nil {
+			t.Fatalf("replay %d: %v", v, err)
+		}
+		if got.Version != v || !reflect.DeepEqual(got.State, want) {
+			t.Fatalf("replay %d: version %d, state differs", v, got.Version)
+		}
+	}
+	if latest, err := e.Replay(ctx, s.ID, 0); err != nil || latest.Version != s.Version {
+		t.Fatalf("replay latest: %v %d", err, latest.Version)
+	}
+	if _, err := e.Replay(ctx, s.ID, s.Version+1); !errors.Is(err, engine.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+	if _, err := e.History(ctx, "missing"); !errors.Is(err, engine.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestEngine_ReplayKeepsRules(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemory()
+	rules := sixtysix.DefaultRules()
+	rules.DeckSize, rules.HandSize = 20, 5
+	e := engine.New(mem)
+	e.Register(sixtysix.Game{Rules: &rules})
+	s, err := e.CreateSession(ctx, "sixtysix", 9)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// a server restarted with the default rules still replays the old deal
+	restarted := engine.New(mem)
+	restarted.Register(sixtysix.Game{})
+	got, err := restarted.Replay(ctx, s.ID, 1)
+	if err != nil || !reflect.DeepEqual(got.State, s.State) {
+		t.Fatalf("replay with other defaults: %v %+v", err, got.State)
+	}
+}
+
+func TestEngine_Fork(t *testing.T) {
+	ctx := context.Background()
+	e := engine.New(store.NewEventSourced(2, sixtysix.Game{}))
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateRatedSession(ctx, "sixtysix", 3, nil)
+	played := play(t, e, s, 3)
+
+	f, err := e.Fork(ctx, s.ID, 2)
+	if err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	if f.ID == s.ID || f.Parent != s.ID || f.ParentVersion != 2 || f.Version != 2 || f.Rated || len(f.Tokens) != 2 || f.Tokens[0] == s.Tokens[0] {
+		t.Fatalf("fork: %+v", f)
+	}
+	if !reflect.DeepEqual(f.State, played[0].State) {
+		t.Fatalf("fork state differs from version 2")
+	}
+	if h, _ := e.History(ctx, f.ID); len(h) != 2 {
+		t.Fatalf("fork history: %d records", len(h))
+	}
+
+	// the fork plays on independently
+	fp := play(t, e, f, 2)
+	if fp[1].Version != 4 {
+		t.Fatalf("fork version: %d", fp[1].Version)
+	}
+	if got, _ := e.GetSession(ctx, s.ID); got.Version != 4 || !reflect.DeepEqual(got.State, played[2].State) {
+		t.Fatalf("parent changed by fork")
+	}
+	if _, err := e.Undo(ctx, f.ID, 1); err != nil {
+		t.Fatalf("undo in fork: %v", err)
+	}
+	if _, err := e.Fork(ctx, s.ID, 9); !errors.Is(err, engine.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+}
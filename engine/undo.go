@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Takeback is a request by Seat to undo the last Actions actions, awaiting the
+// consent of another seat. Seat is -1 in sessions without seats.
+type Takeback struct {
+	Seat    int `json:"seat"`
+	Actions int `json:"actions"`
+}
+
+// Undo rewinds a session by n actions: the state n actions back in the current
+// line of play is rebuilt from the history and stored as a new version, so
+// versions keep increasing and the history records the rewind. Rated and
+// finished sessions cannot be rewound.
+func (e *Engine) Undo(ctx context.Context, id string, n int) (Session, error) {
+	s, err := e.GetSession(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	if s.Rated {
+		return Session{}, ErrRated
+	}
+	return e.undo(ctx, s, n)
+}
+
+// RequestTakeback asks the other seats to let seat undo n actions. It replaces
+// any pending request and, like answering it, creates a version, so that an
+// answer can be tied to the request it saw.
+func (e *Engine) RequestTakeback(ctx context.Context, id string, seat, n int) (Session, error) {
+	s, err := e.GetSession(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	switch {
+	case s.Rated:
+		return Session{}, ErrRated
+	case s.Finished:
+		return Session{}, ErrGameOver
+	}
+	if _, err := e.rewindTarget(ctx, s, n); err != nil {
+		return Session{}, err
+	}
+	return e.setTakeback(ctx, s, &Takeback{Seat: seat, Actions: n})
+}
+
+// AnswerTakeback accepts or declines the pending takeback on behalf of seat,
+// which must not be the requesting seat (ErrOwnTakeback). Accepting undoes the
+// requested actions as Undo does. expectedVersion, when non-zero, makes the
+// answer fail with ErrConflict unless the session is still at that version,
+// i.e. the request is still the one the seat saw.
+func (e *Engine) AnswerTakeback(ctx context.Context, id string, seat int, accept bool, expectedVersion int) (Session, error) {
+	s, err := e.GetSession(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+	if expectedVersion != 0 && expectedVersion != s.Version {
+		return Session{}, ErrConflict
+	}
+	t := s.Takeback
+	switch {
+	case t == nil:
+		return Session{}, ErrNoTakeback
+	case t.Seat >= 0 && t.Seat == seat:
+		return Session{}, ErrOwnTakeback
+	}
+	if accept {
+		return e.undo(ctx, s, t.Actions)
+	}
+	return e.setTakeback(ctx, s, nil)
+}
+
+// setTakeback stores t as the pending request of s in a new version.
+func (e *Engine) setTakeback(ctx context.Context, s Session, t *Takeback) (Session, error) {
+	prev := s.Version
+	s.Version++
+	s.UpdatedAt = time.Now().UTC()
+	s.Takeback = t
+	rec := Record{Version: s.Version, Kind: RecordTakeback, At: s.UpdatedAt, Takeback: t}
+	if err := e.store.Commit(ctx, s, prev, rec, ""); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+func (e *Engine) undo(ctx context.Context, s Session, n int) (Session, error) {
+	if s.Finished {
+		return Session{}, ErrGameOver
+	}
+	g, err := e.game(s.GameName)
+	if err != nil {
+		return Session{}, err
+	}
+	recs, err := e.rewindTarget(ctx, s, n)
+	if err != nil {
+		return Session{}, err
+	}
+	state, err := Project(g, nil, recs)
+	if err != nil {
+		return Session{}, err
+	}
+	prev := s.Version
+	s.State = state
+	s.Finished = finished(g, state)
+	s.Version++
+	s.UpdatedAt = time.Now().UTC()
+	s.Takeback = nil
+	rec := Record{Version: s.Version, Kind: RecordRewind, At: s.UpdatedAt, To: recs[len(recs)-1].Version}
+	if err := e.store.Commit(ctx, s, prev, rec, ""); err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+// rewindTarget returns the history of s up to the version n actions back in
+// its current line of play, or ErrCannotUndo.
+func (e *Engine) rewindTarget(ctx context.Context, s Session, n int) ([]Record, error) {
+	recs, err := e.store.History(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	l := line(recs)
+	if n < 1 || n >= len(l) {
+		return nil, ErrCannotUndo
+	}
+	to := l[len(l)-1-n]
+	i := slices.IndexFunc(recs, func(r Record) bool { return r.Version == to })
+	return recs[:i+1], nil
+}
+
+// line returns the versions making up the current line of play, oldest first:
+// a rewind drops the versions after its target and stands in for the target.
+// Takeback records are not part of it.
+func line(recs []Record) []int {
+	var out []int
+	for _, r := range recs {
+		if r.Kind == RecordTakeback {
+			continue
+		}
+		if r.Kind == RecordRewind {
+			if i := slices.Index(out, r.To); i >= 0 {
+				out = out[:i]
+			}
+		}
+		out = append(out, r.Version)
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"go.rumenx.com/sixtysix"
+	"go.rumenx.com/sixtysix/engine"
+	"go.rumenx.com/sixtysix/store"
+)
+
+// play applies the first legal action for the seat to move n times, returning
+// the session after each.
+func play(t *testing.T, e *engine.Engine, s engine.Session, n int) []engine.Session {
+	t.Helper()
+	var out []engine.Session
+	for i := 0; i < n; i++ {
+		actor := strconv.Itoa(sixtysix.Game{}.ToMove(s.State))
+		actions, err := e.LegalActions(context.Background(), s.ID, actor)
+		if err != nil || len(actions) == 0 {
+			t.Fatalf("legal actions: %v %d", err, len(actions))
+		}
+		a := actions[0]
+		a.Actor = actor
+		if s, err = e.ApplyAction(context.Background(), s.ID, a); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestEngine_Undo(t *testing.T) {
+	ctx := context.Background()
+	e := engine.New(store.NewMemory())
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateSession(ctx, "sixtysix", 5)
+	played := play(t, e, s, 3) // versions 2-4
+
+	u, err := e.Undo(ctx, s.ID, 2)
+	if err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if u.Version != 5 || !reflect.DeepEqual(u.State, played[0].State) {
+		t.Fatalf("undo 2: version %d, state of version 2 expected", u.Version)
+	}
+	// the rewind stands in for version 2, so one more undo reaches the deal
+	if u, err = e.Undo(ctx, s.ID, 1); err != nil || u.Version != 6 || !reflect.DeepEqual(u.State, s.State) {
+		t.Fatalf("undo 1: %v version %d", err, u.Version)
+	}
+	if _, err := e.Undo(ctx, s.ID, 1); !errors.Is(err, engine.ErrCannotUndo) {
+		t.Fatalf("expected ErrCannotUndo, got %v", err)
+	}
+	// replays follow the rewinds
+	if r, err := e.Replay(ctx, s.ID, 5); err != nil || !reflect.DeepEqual(r.State, played[0].State) {
+		t.Fatalf("replay: %v", err)
+	}
+
+	rated, _ := e.CreateRatedSession(ctx, "sixtysix", 5, nil)
+	play(t, e, rated, 1)
+	if _, err := e.Undo(ctx, rated.ID, 1); !errors.Is(err, engine.ErrRated) {
+		t.Fatalf("expected ErrRated, got %v", err)
+	}
+	if _, err := e.RequestTakeback(ctx, rated.ID, 0, 1); !errors.Is(err, engine.ErrRated) {
+		t.Fatalf("expected ErrRated, got %v", err)
+	}
+}
+
+func TestEngine_UndoForgetsIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	for _, st := range []engine.Store{store.NewMemory(), store.NewEventSourced(0, sixtysix.Game{})} {
+		e := engine.New(st)
+		e.Register(sixtysix.Game{})
+		s, _ := e.CreateSession(ctx, "sixtysix", 5)
+		hand := s.State.(sixtysix.State).Hands[0]
+		lead := engine.Action{Type: sixtysix.ActionPlay, Payload: map[string]any{"card": hand[0]}, IdempotencyKey: "k"}
+		if _, err := e.ApplyAction(ctx, s.ID, lead); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+		if _, err := e.Undo(ctx, s.ID, 1); err != nil {
+			t.Fatalf("undo: %v", err)
+		}
+		// the undone result is not handed out again; the lead is replayed
+		again, err := e.ApplyAction(ctx, s.ID, lead)
+		if err != nil || again.Version != 4 {
+			t.Fatalf("%T: retry after undo: %v version %d", st, err, again.Version)
+		}
+	}
+}
+
+func TestEngine_Takeback(t *testing.T) {
+	ctx := context.Background()
+	e := engine.New(store.NewEventSourced(2, sixtysix.Game{}))
+	e.Register(sixtysix.Game{})
+	s, _ := e.CreateSession(ctx, "sixtysix", 5)
+	played := play(t, e, s, 2)
+	seat := sixtysix.Game{}.ToMove(played[0].State)
+
+	r, err := e.RequestTakeback(ctx, s.ID, seat, 1)
+	if err != nil || r.Takeback == nil || r.Version != 4 || !reflect.DeepEqual(r.State, played[1].State) {
+		t.Fatalf("request: %v %+v", err, r)
+	}
+	if _, err := e.AnswerTakeback(ctx, s.ID, seat, true, 0); !errors.Is(err, engine.ErrOwnTakeback) {
+		t.Fatalf("expected the requester not to answer, got %v", err)
+	}
+	// an answer to a request that has since been replaced is refused
+	if _, err := e.RequestTakeback(ctx, s.ID, seat, 2); err != nil {
+		t.Fatalf("replace request: %v", err)
+	}
+	if _, err := e.AnswerTakeback(ctx, s.ID, 1-seat, true, r.Version); !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected ErrConflict for a replaced request, got %v", err)
+	}
+	d, err := e.AnswerTakeback(ctx, s.ID, 1-seat, false, 5)
+	if err != nil || d.Takeback != nil || d.Version != 6 {
+		t.Fatalf("decline: %v %+v", err, d)
+	}
+
+	// a move cancels a pending request
+	if _, err := e.RequestTakeback(ctx, s.ID, seat, 1); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	played = append(played, play(t, e, played[1], 1)...)
+	if got, _ := e.GetSession(ctx, s.ID); got.Takeback != nil || got.Version != 8 {
+		t.Fatalf("takeback should be cancelled by a move: %+v", got)
+	}
+
+	// takeback records are not actions to undo
+	if _, err := e.RequestTakeback(ctx, s.ID, seat, 2); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	a, err := e.AnswerTakeback(ctx, s.ID, 1-seat, true, 9)
+	if err != nil || a.Version != 10 || a.Takeback != nil || !reflect.DeepEqual(a.State, played[0].State) {
+		t.Fatalf("accept: %v %+v", err, a)
+	}
+	if got, _ := e.GetSession(ctx, s.ID); !reflect.DeepEqual(got.State, played[0].State) {
+		t.Fatalf("stored state does not match the rewind")
+	}
+	if _, err := e.AnswerTakeback(ctx, s.ID, 1-seat, true, 0); !errors.Is(err, engine.ErrNoTakeback) {
+		t.Fatalf("expected ErrNoTakeback, got %v", err)
+	}
+	h, _ := e.History(ctx, s.ID)
+	if h[3].Kind != engine.RecordTakeback || h[3].Takeback == nil || h[3].Takeback.Actions != 1 || h[5].Takeback != nil {
+		t.Fatalf("takeback records: %+v", h)
+	}
+	if got, err := e.Replay(ctx, s.ID, 4); err != nil || !reflect.DeepEqual(got.State, played[1].State) {
+		t.Fatalf("replay of a takeback version: %v", err)
+	}
+}
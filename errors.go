@@ -0,0 +1,38 @@
+package sixtysix
+
+import "go.rumenx.com/sixtysix/engine"
+
+// Validation errors. Each has a stable code for clients; errors about a card
+// carry it in Details["card"]. Compare with errors.Is.
+var (
+	ErrDealOver           = engine.NewError("dealOver", "game over")
+	ErrUnknownAction      = engine.NewError("unknownAction", "unknown action")
+	ErrMissingCard        = engine.NewError("missingCard", "missing card")
+	ErrCardNotInHand      = engine.NewError("cardNotInHand", "card not in hand")
+	ErrMustLeadMarriage   = engine.NewError("mustLeadMarriage", "must lead the king or queen of the declared marriage")
+	ErrMustFollowSuit     = engine.NewError("mustFollowSuit", "must follow suit")
+	ErrMustHeadTrick      = engine.NewError("mustHeadTrick", "must head the trick with a higher card")
+	ErrMustTrump          = engine.NewError("mustTrump", "must trump when unable to follow suit")
+	ErrMustOvertrump      = engine.NewError("mustOvertrump", "must overtrump")
+	ErrCannotClose        = engine.NewError("cannotClose", "cannot close")
+	ErrCloseNotAtLead     = engine.NewError("closeNotAtLead", "only the leader may close, before playing")
+	ErrCloseTooLate       = engine.NewError("closeTooLate", "cannot close with only two cards left")
+	ErrMissingSuit        = engine.NewError("missingSuit", "missing suit")
+	ErrNoMarriage         = engine.NewError("noMarriage", "no marriage")
+	ErrDeclareNotAtLead   = engine.NewError("declareNotAtLead", "declare only on lead")
+	ErrOneMarriagePerLead = engine.NewError("oneMarriagePerLead", "one marriage per lead")
+	ErrMarriageDeclared   = engine.NewError("marriageDeclared", "marriage already declared")
+	ErrCannotExchange     = engine.NewError("cannotExchange", "cannot exchange when stock closed or empty")
+	ErrExchangeNotAtLead  = engine.NewError("exchangeNotAtLead", "exchange only at lead")
+	ErrNoExchangeTrump    = engine.NewError("noExchangeTrump", "no lowest trump to exchange")
+	ErrExchangeNeedsTrick = engine.NewError("exchangeNeedsTrick", "exchange only after winning a trick")
+	ErrExchangeTooLate    = engine.NewError("exchangeTooLate", "cannot exchange with only two cards left")
+	ErrAutoWin            = engine.NewError("autoWin", "66 is scored automatically")
+	ErrAnnounceNotAtLead  = engine.NewError("announceNotAtLead", "announce only on lead")
+	ErrMatchOver          = engine.NewError("matchOver", "match over")
+	ErrDealInProgress     = engine.NewError("dealInProgress", "deal in progress")
+	ErrAwaitingDeal       = engine.NewError("awaitingDeal", "deal over")
+	ErrInvalidRules       = engine.NewError("invalidRules", "sixtysix: invalid rules")
+	ErrInvalidCard        = engine.NewError("invalidCard", "sixtysix: invalid card")
+	ErrInvalidState       = engine.NewError("invalidState", "sixtysix: invalid state")
+)
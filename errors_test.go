@@ -0,0 +1,30 @@
+package sixtysix
+
+import (
+	"errors"
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+func TestValidationErrorsAreTyped(t *testing.T) {
+	g := Game{}
+	st := State{Current: 1, Hands: [][]int{{}, {card(Hearts, 3), card(Clubs, 11)}}, Closed: true, ClosedBy: 0, TrumpSuit: Spades, Trick: []int{card(Hearts, 10)}, Winner: -1}
+	err := g.Validate(st, actionPlay(card(Clubs, 11)))
+	var e *engine.Error
+	if !errors.Is(err, ErrMustFollowSuit) || !errors.As(err, &e) || e.Code != "mustFollowSuit" || e.Details["card"] != NewCard(Clubs, Ace) {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if err := g.Validate(st, actionPlay(card(Spades, 11))); !errors.Is(err, ErrCardNotInHand) {
+		t.Fatalf("expected ErrCardNotInHand, got %v", err)
+	}
+	if err := g.Validate(st, engine.Action{Type: "bogus"}); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("expected ErrUnknownAction, got %v", err)
+	}
+	if _, err := ParseRules(DefaultRules(), []byte(`{"deckSize":7}`)); !errors.Is(err, ErrInvalidRules) || err.Error() != "sixtysix: deckSize must be 20 or 24" {
+		t.Fatalf("expected ErrInvalidRules, got %v", err)
+	}
+	if _, err := ParseCard("ZZ"); !errors.Is(err, ErrInvalidCard) {
+		t.Fatalf("expected ErrInvalidCard, got %v", err)
+	}
+}
@@ -21,11 +21,18 @@ var (
 
 func main() {
 	port := flag.String("port", "8080", "listen port")
+	events := flag.Bool("events", false, "use the event-sourced store")
 	flag.Parse()
 
-	mem := store.NewMemory()
-	e := engine.New(mem)
-	e.Register(sixtysix.Game{})
+	games := []engine.Game{sixtysix.Game{}, sixtysix.Match{}, sixtysix.Schnapsen{}, sixtysix.Partnership{}}
+	var st engine.Store = store.NewMemory()
+	if *events {
+		st = store.NewEventSourced(store.DefaultSnapshotEvery, games...)
+	}
+	e := engine.New(st)
+	for _, g := range games {
+		e.Register(g)
+	}
 
 	srv := api.New(e)
 	addr := ":" + *port
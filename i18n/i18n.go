@@ -0,0 +1,158 @@
+// Package i18n holds localized message templates for error codes and game
+// events, keyed by locale, and picks a locale from an Accept-Language header.
+//
+// Templates may reference details by name in braces, e.g. "Card {card} is not
+// in your hand"; values are formatted with fmt, so a sixtysix.Card renders as
+// "A♥". Events use the key "outcome.<reason>" with the details winner and
+// gamePoints, or "outcome.team.<reason>" when the winner is a team.
+package i18n
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog maps locales to message templates. It is safe for concurrent use.
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback string
+	messages map[string]map[string]string
+}
+
+// New returns an empty catalog that falls back to the fallback locale.
+func New(fallback string) *Catalog {
+	return &Catalog{fallback: normalize(fallback), messages: map[string]map[string]string{}}
+}
+
+// Default returns a catalog with the built-in English, Bulgarian and German
+// messages, falling back to English. English error messages are left to the
+// errors themselves, so only English events are included.
+func Default() *Catalog {
+	c := New("en")
+	for locale, msgs := range builtin {
+		c.Register(locale, msgs)
+	}
+	return c
+}
+
+// Register adds or replaces templates for locale, e.g. "bg" or "de-AT".
+func (c *Catalog) Register(locale string, messages map[string]string) {
+	locale = normalize(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.messages[locale]
+	if m == nil {
+		m = map[string]string{}
+		c.messages[locale] = m
+	}
+	maps.Copy(m, messages)
+}
+
+// Locales returns the registered locales, sorted.
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Match returns the best registered locale for an Accept-Language header,
+// honouring q-values and falling back from "de-AT" to "de". Without a match it
+// returns the fallback locale.
+func (c *Catalog) Match(acceptLanguage string) string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if tag != "" && q > 0 {
+			prefs = append(prefs, pref{normalize(tag), q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, p := range prefs {
+		if _, ok := c.messages[p.tag]; ok {
+			return p.tag
+		}
+		if base, _, ok := strings.Cut(p.tag, "-"); ok {
+			if _, ok := c.messages[base]; ok {
+				return base
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Message renders the template for key in locale, trying the locale's base
+// language and then the fallback locale. It reports false if no template
+// exists.
+func (c *Catalog) Message(locale, key string, details map[string]any) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, l := range c.chain(locale) {
+		if t, ok := c.messages[l][key]; ok {
+			return render(t, details), true
+		}
+	}
+	return "", false
+}
+
+// Messages returns every template visible in locale, including those
+// inherited from its base language and the fallback locale.
+func (c *Catalog) Messages(locale string) map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := map[string]string{}
+	chain := c.chain(locale)
+	for i := len(chain) - 1; i >= 0; i-- {
+		maps.Copy(out, c.messages[chain[i]])
+	}
+	return out
+}
+
+// chain lists the locales consulted for locale, most specific first.
+func (c *Catalog) chain(locale string) []string {
+	locale = normalize(locale)
+	out := []string{locale}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		out = append(out, base)
+	}
+	if !slices.Contains(out, c.fallback) {
+		out = append(out, c.fallback)
+	}
+	return out
+}
+
+func render(t string, details map[string]any) string {
+	if len(details) == 0 {
+		return t
+	}
+	pairs := make([]string, 0, 2*len(details))
+	for k, v := range details {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(t)
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
@@ -0,0 +1,104 @@
+package i18n_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/sixtysix"
+	"go.rumenx.com/sixtysix/engine"
+	"go.rumenx.com/sixtysix/i18n"
+)
+
+func TestCatalog_Match(t *testing.T) {
+	c := i18n.Default()
+	for header, want := range map[string]string{
+		"":                        "en",
+		"bg":                      "bg",
+		"de-AT,de;q=0.9":          "de",
+		"fr, bg;q=0.5, de;q=0.8":  "de",
+		"fr":                      "en",
+		"BG-bg":                   "bg",
+		"de;q=0, bg;q=0.1, en-GB": "en",
+	} {
+		if got := c.Match(header); got != want {
+			t.Fatalf("Match(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCatalog_MessageAndRegister(t *testing.T) {
+	c := i18n.Default()
+	msg, ok := c.Message("de", "cardNotInHand", map[string]any{"card": sixtysix.NewCard(sixtysix.Hearts, sixtysix.Ace)})
+	if !ok || msg != "Die Karte A♥ ist nicht auf der Hand" {
+		t.Fatalf("unexpected message: %q %v", msg, ok)
+	}
+	if _, ok := c.Message("en", "mustFollowSuit", nil); ok {
+		t.Fatalf("English errors should be left to the error itself")
+	}
+	if msg, _ := c.Message("bg-BG", "outcome.tied", nil); msg != "Раздаването завърши наравно" {
+		t.Fatalf("expected region to fall back to its language, got %q", msg)
+	}
+
+	c.Register("de-AT", map[string]string{"mustTrump": "Stechen ist Pflicht"})
+	c.Register("fr", map[string]string{"mustFollowSuit": "Il faut fournir"})
+	if got := c.Match("de-AT"); got != "de-at" {
+		t.Fatalf("expected registered region to match, got %q", got)
+	}
+	if msg, _ := c.Message("de-AT", "mustTrump", nil); msg != "Stechen ist Pflicht" {
+		t.Fatalf("unexpected override: %q", msg)
+	}
+	if msg, _ := c.Message("de-AT", "mustFollowSuit", nil); msg != "Farbe muss bedient werden" {
+		t.Fatalf("expected fallback to de, got %q", msg)
+	}
+	if !reflect.DeepEqual(c.Locales(), []string{"bg", "de", "de-at", "en", "fr"}) {
+		t.Fatalf("unexpected locales: %v", c.Locales())
+	}
+	if m := c.Messages("fr"); m["mustFollowSuit"] != "Il faut fournir" || m["outcome.tied"] != "The deal is tied" {
+		t.Fatalf("unexpected merged messages: %v", m)
+	}
+}
+
+// Every code the library can return has a Bulgarian and German translation,
+// and every deal outcome an event message in each built-in locale.
+func TestCatalog_Complete(t *testing.T) {
+	codes := []*engine.Error{
+		engine.ErrGameNotFound, engine.ErrSessionNotFound, engine.ErrConflict, engine.ErrUnauthorized,
+		engine.ErrNotYourTurn, engine.ErrGameOver, engine.ErrNoOptions, engine.ErrVersionNotFound,
+		engine.ErrRated, engine.ErrCannotUndo, engine.ErrNoTakeback, engine.ErrNoCustomState,
+		engine.ErrOwnTakeback,
+		sixtysix.ErrDealOver, sixtysix.ErrUnknownAction, sixtysix.ErrMissingCard, sixtysix.ErrCardNotInHand,
+		sixtysix.ErrMustLeadMarriage, sixtysix.ErrMustFollowSuit, sixtysix.ErrMustHeadTrick, sixtysix.ErrMustTrump,
+		sixtysix.ErrMustOvertrump, sixtysix.ErrCannotClose, sixtysix.ErrCloseNotAtLead, sixtysix.ErrCloseTooLate,
+		sixtysix.ErrMissingSuit, sixtysix.ErrNoMarriage, sixtysix.ErrDeclareNotAtLead, sixtysix.ErrOneMarriagePerLead,
+		sixtysix.ErrMarriageDeclared, sixtysix.ErrCannotExchange, sixtysix.ErrExchangeNotAtLead,
+		sixtysix.ErrNoExchangeTrump, sixtysix.ErrExchangeNeedsTrick, sixtysix.ErrExchangeTooLate, sixtysix.ErrAutoWin,
+		sixtysix.ErrAnnounceNotAtLead, sixtysix.ErrMatchOver, sixtysix.ErrDealInProgress, sixtysix.ErrAwaitingDeal,
+		sixtysix.ErrInvalidRules, sixtysix.ErrInvalidCard, sixtysix.ErrInvalidState,
+	}
+	reasons := []string{sixtysix.ReasonReached66, sixtysix.ReasonLastTrick, sixtysix.ReasonCloserFailed,
+		sixtysix.ReasonAnnounced, sixtysix.ReasonFalseClaim, sixtysix.ReasonTied}
+	c := i18n.Default()
+	for _, locale := range []string{"bg", "de"} {
+		m := c.Messages(locale)
+		for _, e := range codes {
+			if strings.TrimSpace(m[e.Code]) == "" {
+				t.Fatalf("%s: no message for %s", locale, e.Code)
+			}
+		}
+	}
+	for _, locale := range []string{"en", "bg", "de"} {
+		for _, r := range reasons {
+			msg, ok := c.Message(locale, "outcome."+r, map[string]any{"winner": 1, "gamePoints": 2})
+			if !ok || strings.Contains(msg, "{") {
+				t.Fatalf("%s: bad event message for %s: %q", locale, r, msg)
+			}
+		}
+		for _, r := range []string{sixtysix.ReasonReached66, sixtysix.ReasonTied} {
+			msg, ok := c.Message(locale, "outcome.team."+r, map[string]any{"winner": 1, "gamePoints": 2})
+			if !ok || strings.Contains(msg, "{") || strings.Contains(msg, "Seat") {
+				t.Fatalf("%s: bad team event message for %s: %q", locale, r, msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package i18n
+
+// builtin holds the shipped translations, keyed by locale and then by error
+// code, "outcome.<reason>" or "outcome.team.<reason>".
+var builtin = map[string]map[string]string{
+	"en": {
+		"outcome.reached66":    "Seat {winner} reached 66 ({gamePoints} game points)",
+		"outcome.lastTrick":    "Seat {winner} took the last trick ({gamePoints} game points)",
+		"outcome.closerFailed": "The closer failed; seat {winner} wins ({gamePoints} game points)",
+		"outcome.announced66":  "Seat {winner} announced 66 ({gamePoints} game points)",
+		"outcome.falseClaim":   "False claim of 66; seat {winner} wins ({gamePoints} game points)",
+		"outcome.tied":         "The deal is tied",
+
+		"outcome.team.reached66": "Team {winner} reached 66 ({gamePoints} game points)",
+		"outcome.team.tied":      "The deal is tied",
+	},
+	"bg": {
+		"dealOver":           "Раздаването приключи",
+		"unknownAction":      "Непознато действие",
+		"missingCard":        "Не е посочена карта",
+		"cardNotInHand":      "Картата {card} не е в ръката ви",
+		"mustLeadMarriage":   "Трябва да изиграете попа или дамата от обявената двойка",
+		"mustFollowSuit":     "Трябва да отговорите на боята",
+		"mustHeadTrick":      "Трябва да качите с по-силна карта",
+		"mustTrump":          "Трябва да играете коз, когато нямате от исканата боя",
+		"mustOvertrump":      "Трябва да качите с по-силен коз",
+		"cannotClose":        "Тестето не може да бъде затворено",
+		"closeNotAtLead":     "Само водещият може да затвори, преди да е изиграл карта",
+		"closeTooLate":       "Не може да затворите, когато са останали само две карти",
+		"missingSuit":        "Не е посочена боя",
+		"noMarriage":         "Нямате поп и дама от тази боя",
+		"declareNotAtLead":   "Обявяване е възможно само при водене",
+		"oneMarriagePerLead": "Само едно обявяване на ход",
+		"marriageDeclared":   "Тази двойка вече е обявена",
+		"cannotExchange":     "Козът не може да се сменя при затворено или изчерпано тесте",
+		"exchangeNotAtLead":  "Смяна на коза е възможна само при водене",
+		"noExchangeTrump":    "Нямате най-ниския коз за смяна",
+		"exchangeNeedsTrick": "Смяна на коза е възможна след спечелена ръка",
+		"exchangeTooLate":    "Козът не може да се сменя, когато са останали само две карти",
+		"autoWin":            "66 се отчита автоматично",
+		"announceNotAtLead":  "66 се обявява само при водене",
+		"matchOver":          "Мачът приключи",
+		"dealInProgress":     "Раздаването още не е приключило",
+		"awaitingDeal":       "Раздаването приключи; чака се ново раздаване",
+		"invalidRules":       "Невалидни правила",
+		"invalidCard":        "Невалидна карта {card}",
+		"invalidState":       "Невалидна позиция",
+		"gameNotFound":       "Играта не е намерена",
+		"sessionNotFound":    "Сесията не е намерена",
+		"conflict":           "Играта се промени междувременно; опитайте отново",
+		"unauthorized":       "Невалиден жетон за място",
+		"notYourTurn":        "Не е ваш ред",
+		"gameOver":           "Играта приключи",
+		"noOptions":          "Играта не приема настройки",
+		"versionNotFound":    "Версията не е намерена",
+		"rated":              "Връщане на ходове не е позволено в игри с рейтинг",
+		"cannotUndo":         "Няма толкова ходове за връщане",
+		"noTakeback":         "Няма искане за връщане на ход",
+		"ownTakeback":        "Не можете да отговорите на собственото си искане за връщане на ход",
+		"noCustomState":      "Играта не може да започне от зададена позиция",
+		"methodNotAllowed":   "Методът не е позволен",
+		"missingGame":        "Не е посочена игра",
+		"invalidJSON":        "Невалиден JSON",
+		"tokenRequired":      "Необходим е жетон за място",
+		"invalidSeat":        "Невалидно място",
+		"seatMismatch":       "Жетонът не съответства на мястото",
+		"invalidIfMatch":     "Невалиден If-Match",
+		"invalidVersion":     "Невалидна версия",
+		"notFinished":        "Сесията още не е приключила",
+		"stateWithOptions":   "Зададена позиция не може да се комбинира със seed, rules или rated",
+		"invalid":            "Невалидна заявка",
+
+		"outcome.reached66":    "Място {winner} достигна 66 ({gamePoints} точки за игра)",
+		"outcome.lastTrick":    "Място {winner} взе последната ръка ({gamePoints} точки за игра)",
+		"outcome.closerFailed": "Затворилият не успя; място {winner} печели ({gamePoints} точки за игра)",
+		"outcome.announced66":  "Място {winner} обяви 66 ({gamePoints} точки за игра)",
+		"outcome.falseClaim":   "Невярно обявяване на 66; място {winner} печели ({gamePoints} точки за игра)",
+		"outcome.tied":         "Раздаването завърши наравно",
+
+		"outcome.team.reached66": "Отбор {winner} достигна 66 ({gamePoints} точки за игра)",
+		"outcome.team.tied":      "Раздаването завърши наравно",
+	},
+	"de": {
+		"dealOver":           "Das Spiel ist vorbei",
+		"unknownAction":      "Unbekannte Aktion",
+		"missingCard":        "Keine Karte angegeben",
+		"cardNotInHand":      "Die Karte {card} ist nicht auf der Hand",
+		"mustLeadMarriage":   "König oder Dame des angesagten Paares muss ausgespielt werden",
+		"mustFollowSuit":     "Farbe muss bedient werden",
+		"mustHeadTrick":      "Der Stich muss mit einer höheren Karte übernommen werden",
+		"mustTrump":          "Wer nicht bedienen kann, muss stechen",
+		"mustOvertrump":      "Es muss übertrumpft werden",
+		"cannotClose":        "Der Talon kann nicht zugedreht werden",
+		"closeNotAtLead":     "Nur der Ausspieler darf zudrehen, bevor er spielt",
+		"closeTooLate":       "Bei nur zwei verbleibenden Karten kann nicht zugedreht werden",
+		"missingSuit":        "Keine Farbe angegeben",
+		"noMarriage":         "Kein König und keine Dame dieser Farbe",
+		"declareNotAtLead":   "Ansagen nur beim Ausspielen",
+		"oneMarriagePerLead": "Nur eine Ansage pro Ausspiel",
+		"marriageDeclared":   "Dieses Paar wurde bereits angesagt",
+		"cannotExchange":     "Austauschen ist bei zugedrehtem oder leerem Talon nicht möglich",
+		"exchangeNotAtLead":  "Austauschen nur beim Ausspielen",
+		"noExchangeTrump":    "Kein niedrigster Trumpf zum Austauschen",
+		"exchangeNeedsTrick": "Austauschen erst nach einem gewonnenen Stich",
+		"exchangeTooLate":    "Bei nur zwei verbleibenden Karten kann nicht ausgetauscht werden",
+		"autoWin":            "66 wird automatisch gewertet",
+		"announceNotAtLead":  "66 nur beim Ausspielen ansagen",
+		"matchOver":          "Die Partie ist vorbei",
+		"dealInProgress":     "Das Spiel läuft noch",
+		"awaitingDeal":       "Das Spiel ist vorbei; es muss neu gegeben werden",
+		"invalidRules":       "Ungültige Regeln",
+		"invalidCard":        "Ungültige Karte {card}",
+		"invalidState":       "Ungültige Stellung",
+		"gameNotFound":       "Spiel nicht gefunden",
+		"sessionNotFound":    "Sitzung nicht gefunden",
+		"conflict":           "Die Sitzung hat sich inzwischen geändert; bitte erneut versuchen",
+		"unauthorized":       "Ungültiges Platz-Token",
+		"notYourTurn":        "Du bist nicht am Zug",
+		"gameOver":           "Das Spiel ist beendet",
+		"noOptions":          "Das Spiel akzeptiert keine Optionen",
+		"versionNotFound":    "Version nicht gefunden",
+		"rated":              "In gewerteten Partien kann kein Zug zurückgenommen werden",
+		"cannotUndo":         "So viele Züge können nicht zurückgenommen werden",
+		"noTakeback":         "Keine Rücknahmeanfrage offen",
+		"ownTakeback":        "Die eigene Rücknahmeanfrage kann nicht beantwortet werden",
+		"noCustomState":      "Das Spiel kann nicht aus einer vorgegebenen Stellung beginnen",
+		"methodNotAllowed":   "Methode nicht erlaubt",
+		"missingGame":        "Kein Spiel angegeben",
+		"invalidJSON":        "Ungültiges JSON",
+		"tokenRequired":      "Platz-Token erforderlich",
+		"invalidSeat":        "Ungültiger Platz",
+		"seatMismatch":       "Token passt nicht zum Platz",
+		"invalidIfMatch":     "Ungültiges If-Match",
+		"invalidVersion":     "Ungültige Version",
+		"notFinished":        "Die Sitzung ist noch nicht beendet",
+		"stateWithOptions":   "Eine vorgegebene Stellung kann nicht mit seed, rules oder rated kombiniert werden",
+		"invalid":            "Ungültige Anfrage",
+
+		"outcome.reached66":    "Platz {winner} hat 66 erreicht ({gamePoints} Spielpunkte)",
+		"outcome.lastTrick":    "Platz {winner} hat den letzten Stich gemacht ({gamePoints} Spielpunkte)",
+		"outcome.closerFailed": "Der Zudreher ist gescheitert; Platz {winner} gewinnt ({gamePoints} Spielpunkte)",
+		"outcome.announced66":  "Platz {winner} hat 66 angesagt ({gamePoints} Spielpunkte)",
+		"outcome.falseClaim":   "Falsche Ansage von 66; Platz {winner} gewinnt ({gamePoints} Spielpunkte)",
+		"outcome.tied":         "Das Spiel endet unentschieden",
+
+		"outcome.team.reached66": "Team {winner} hat 66 erreicht ({gamePoints} Spielpunkte)",
+		"outcome.team.tied":      "Das Spiel endet unentschieden",
+	},
+}
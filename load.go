@@ -0,0 +1,183 @@
+package sixtysix
+
+import (
+	"encoding/json"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+// cardState is a State whose cards may be given in card notation.
+type cardState struct {
+	State
+	Hands     [][]Card `json:"hands"`
+	Stock     []Card   `json:"stock"`
+	TrumpCard Card     `json:"trumpCard"`
+	Trick     []Card   `json:"trick"`
+	Won       [][]Card `json:"won"`
+	LastTrick []Card   `json:"lastTrick"`
+	MustPlay  []Card   `json:"mustPlay"`
+}
+
+// LoadState implements engine.StateLoader: raw is a JSON State, e.g. an
+// endgame puzzle or a position from a bug report, with cards as ints or in
+// card notation ("A♥"). Omitted fields take the values of a fresh deal (no
+// closer, no winner, no last trick), omitted rules fields the game's rules,
+// and nil per-seat counters start at zero. The state is rejected with
+// ErrInvalidState unless it is consistent; see State.check.
+func (g Game) LoadState(raw json.RawMessage) (any, error) {
+	rules := g.rules()
+	cs := cardState{State: State{SitOut: -1, ClosedBy: -1, Winner: -1, LastTrickWinner: -1, Rules: &rules}}
+	if err := json.Unmarshal(raw, &cs); err != nil {
+		return nil, ErrInvalidState.Errorf("sixtysix: invalid state: %v", err)
+	}
+	st := cs.State
+	st.Hands, st.Won = cardPiles(cs.Hands), cardPiles(cs.Won)
+	st.Stock, st.Trick = cardInts(cs.Stock), cardInts(cs.Trick)
+	st.LastTrick, st.MustPlay = cardInts(cs.LastTrick), cardInts(cs.MustPlay)
+	st.TrumpCard = int(cs.TrumpCard)
+	if st.Rules == nil {
+		st.Rules = &rules
+	}
+	if err := st.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	if len(st.Hands) != st.Rules.Players {
+		return nil, ErrInvalidState.Errorf("sixtysix: invalid state: %d hands for %d players", len(st.Hands), st.Rules.Players)
+	}
+	n := len(st.Hands)
+	for _, xs := range [][]int{st.Scores, st.Tricks, st.Pending} {
+		if xs != nil && len(xs) != n {
+			return nil, ErrInvalidState.Errorf("sixtysix: invalid state: per-seat fields need %d entries", n)
+		}
+	}
+	if st.Won != nil && len(st.Won) != n {
+		return nil, ErrInvalidState.Errorf("sixtysix: invalid state: per-seat fields need %d entries", n)
+	}
+	st = st.clone()
+	for i := range st.Hands {
+		sortHand(st.Hands[i])
+	}
+	if err := st.check(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func cardInts(cs []Card) []int {
+	if cs == nil {
+		return nil
+	}
+	out := make([]int, len(cs))
+	for i, c := range cs {
+		out[i] = int(c)
+	}
+	return out
+}
+
+func cardPiles(css [][]Card) [][]int {
+	if css == nil {
+		return nil
+	}
+	out := make([][]int, len(css))
+	for i, cs := range css {
+		out[i] = cardInts(cs)
+	}
+	return out
+}
+
+// check reports an inconsistent state: every card of the deck must be in
+// exactly one place (a hand, the stock, the trick, a won pile, or face up as
+// the trump card while the stock lasts), hands must hold as many cards as the
+// stock and trick allow, the trump suit must be the trump card's, and a closed
+// stock needs a closer whose opponent has since kept or raised its score.
+func (st State) check() error {
+	rules := st.rules()
+	n := len(st.Hands)
+	invalid := func(format string, args ...any) *engine.Error {
+		return ErrInvalidState.Errorf("sixtysix: invalid state: "+format, args...)
+	}
+	switch {
+	case n == 2 && st.SitOut != -1, n == 3 && (st.SitOut < 0 || st.SitOut >= n):
+		return invalid("sitOut %d", st.SitOut)
+	case st.Current < 0 || st.Current >= n || st.Current == st.SitOut:
+		return invalid("seat %d cannot be current", st.Current)
+	case st.SitOut >= 0 && len(st.Hands[st.SitOut]) > 0:
+		return invalid("seat %d sits out but holds cards", st.SitOut)
+	case len(st.Trick) > 1:
+		return invalid("trick holds %d cards", len(st.Trick))
+	case st.DealOver != (st.Outcome != nil):
+		return invalid("dealOver and outcome disagree")
+	case st.Closed != (st.ClosedBy >= 0):
+		return invalid("closed and closedBy %d disagree", st.ClosedBy)
+	case st.ClosedBy >= n || (st.ClosedBy >= 0 && st.ClosedBy == st.SitOut):
+		return invalid("seat %d cannot have closed", st.ClosedBy)
+	case st.ClosedBy >= 0 && st.OpponentPointsAtClose > st.Scores[st.opponent(st.ClosedBy)],
+		st.ClosedBy >= 0 && st.OpponentTricksAtClose > st.Tricks[st.opponent(st.ClosedBy)]:
+		return invalid("opponent at close has more than the opponent now")
+	case st.OpponentPointsAtClose < 0 || st.OpponentTricksAtClose < 0:
+		return invalid("negative opponent score at close")
+	}
+
+	deck := newDeck(rules.DeckSize)
+	seen := make(map[int]bool, len(deck))
+	place := func(cards ...int) error {
+		for _, c := range cards {
+			if !contains(deck, c) {
+				return invalid("%v is not in a %d-card deck", Card(c), rules.DeckSize).With("card", Card(c))
+			}
+			if seen[c] {
+				return invalid("%v appears twice", Card(c)).With("card", Card(c))
+			}
+			seen[c] = true
+		}
+		return nil
+	}
+	groups := [][]int{st.Stock, st.Trick}
+	groups = append(groups, st.Hands...)
+	groups = append(groups, st.Won...)
+	if len(st.Stock) > 0 {
+		groups = append(groups, []int{st.TrumpCard})
+	}
+	for _, g := range groups {
+		if err := place(g...); err != nil {
+			return err
+		}
+	}
+	if len(seen) != len(deck) {
+		return invalid("%d cards in play, want %d", len(seen), len(deck))
+	}
+	if !contains(deck, st.TrumpCard) || cardSuit(st.TrumpCard) != st.TrumpSuit {
+		return invalid("trump suit %d does not match trump card %v", st.TrumpSuit, Card(st.TrumpCard))
+	}
+
+	// the leader of a trick in progress holds one card fewer than the rest
+	want := -1
+	for seat, h := range st.Hands {
+		if seat == st.SitOut {
+			continue
+		}
+		size := len(h)
+		if len(st.Trick) == 1 && seat != st.Current {
+			size++
+		}
+		if want < 0 {
+			want = size
+		}
+		open := !st.Closed && len(st.Stock) > 0
+		if size != want || size > rules.HandSize || (open && size != rules.HandSize) {
+			return invalid("seat %d holds %d cards", seat, len(h))
+		}
+		if len(st.Won[seat]) != 2*st.Tricks[seat] {
+			return invalid("seat %d has %d tricks but %d won cards", seat, st.Tricks[seat], len(st.Won[seat]))
+		}
+		if st.Scores[seat] < 0 || st.Pending[seat] < 0 {
+			return invalid("seat %d has a negative score", seat)
+		}
+	}
+	for _, c := range st.MustPlay {
+		if !contains(st.Hands[st.Current], c) {
+			return invalid("mustPlay %v is not in hand", Card(c)).With("card", Card(c))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadState_RoundTripsPlayedDeals(t *testing.T) {
+	for _, opts := range []string{`{}`, `{"players":3}`, `{"deckSize":20,"handSize":5}`} {
+		g := Game{}
+		s, err := g.InitialStateWith(3, json.RawMessage(opts))
+		if err != nil {
+			t.Fatalf("%s: %v", opts, err)
+		}
+		// every state reached in play must load back unchanged
+		for i := 0; ; i++ {
+			raw, _ := json.Marshal(s)
+			got, err := g.LoadState(raw)
+			if err != nil {
+				t.Fatalf("%s, step %d: %v\n%s", opts, i, err, raw)
+			}
+			want := s.(State).clone()
+			for _, h := range want.Hands {
+				sortHand(h)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s, step %d: loaded state differs", opts, i)
+			}
+			actions := g.LegalActions(s)
+			if len(actions) == 0 {
+				break
+			}
+			if s, err = g.Apply(s, actions[i%len(actions)]); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+		}
+	}
+}
+
+func TestLoadState_Puzzle(t *testing.T) {
+	// an endgame: stock exhausted, three cards each, hearts trumps
+	puzzle := `{
+		"current": 0,
+		"hands": [["A♥", "10♠", "K♣"], ["10♥", "A♠", "Q♣"]],
+		"trumpSuit": 2, "trumpCard": "J♥",
+		"scores": [40, 42],
+		"tricks": [4, 5],
+		"won": [
+			["A♣", "9♣", "10♣", "J♣", "A♦", "10♦", "K♦", "Q♦"],
+			["J♦", "9♦", "K♥", "Q♥", "J♥", "9♥", "K♠", "Q♠", "J♠", "9♠"]
+		]
+	}`
+	s, err := Game{}.LoadState(json.RawMessage(puzzle))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	st := s.(State)
+	if st.Rules == nil || st.Rules.DeckSize != 24 || st.SitOut != -1 || st.ClosedBy != -1 || len(st.Pending) != 2 {
+		t.Fatalf("defaults not applied: %+v", st)
+	}
+
+	closed := puzzle[:len(puzzle)-2] + `, "closed": true, "closedBy": 0, "opponentPointsAtClose": 42, "opponentTricksAtClose": 5}`
+	if _, err := (Game{}).LoadState(json.RawMessage(closed)); err != nil {
+		t.Fatalf("closed puzzle: %v", err)
+	}
+
+	bad := map[string]string{
+		"duplicate card":     `"hands": [["A♥", "A♥", "K♣"], ["10♥", "A♠", "Q♣"]]`,
+		"missing card":       `"hands": [["A♥", "10♠"], ["10♥", "A♠", "Q♣"]]`,
+		"trump mismatch":     `"trumpSuit": 1`,
+		"card not in deck":   `"hands": [["A♥", "10♠", "8♣"], ["10♥", "A♠", "Q♣"]]`,
+		"hand size":          `"hands": [["A♥", "10♠", "K♣", "Q♣"], ["10♥", "A♠"]]`,
+		"closed, no closer":  `"closed": true`,
+		"closer, not closed": `"closedBy": 0`,
+		"closer off table":   `"closed": true, "closedBy": 2`,
+		"points at close":    `"closed": true, "closedBy": 0, "opponentPointsAtClose": 50`,
+		"tricks at close":    `"closed": true, "closedBy": 0, "opponentTricksAtClose": 6`,
+	}
+	for name, field := range bad {
+		var m, override map[string]json.RawMessage
+		_ = json.Unmarshal([]byte(puzzle), &m)
+		if err := json.Unmarshal([]byte("{"+field+"}"), &override); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for k, v := range override {
+			m[k] = v
+		}
+		raw, _ := json.Marshal(m)
+		if _, err := (Game{}).LoadState(raw); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("%s: expected ErrInvalidState, got %v", name, err)
+		}
+	}
+
+	// Schnapsen fills in its own rules, so the 24-card puzzle does not fit
+	if _, err := (Schnapsen{}).LoadState(json.RawMessage(puzzle)); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected the nines to be rejected in Schnapsen, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"slices"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+// DefaultGoal is the number of game points that traditionally wins a match.
+const DefaultGoal = 7
+
+// MatchState is a match of consecutive deals. Deal holds the deal in
+// progress (or the one just finished, until the next "deal" action).
+type MatchState struct {
+	Deal       State     `json:"deal"`
+	DealNumber int       `json:"dealNumber"`
+	Dealer     int       `json:"dealer"`
+	GamePoints []int     `json:"gamePoints"`
+	Goal       int       `json:"goal"`
+	Results    []Outcome `json:"results"`
+	Winner     int       `json:"winner"`
+	// Seed derives the shuffle of every deal; it is hidden from player views.
+	Seed int64 `json:"seed"`
+}
+
+// MatchView is the part of a MatchState visible to a single seat.
+type MatchView struct {
+	Deal       PlayerView `json:"deal"`
+	DealNumber int        `json:"dealNumber"`
+	Dealer     int        `json:"dealer"`
+	GamePoints []int      `json:"gamePoints"`
+	Goal       int        `json:"goal"`
+	Results    []Outcome  `json:"results"`
+	Winner     int        `json:"winner"`
+}
+
+// Match plays deals of Sixty-six until a player collects Goal game points
+// (DefaultGoal when zero). The deal passes to the next seat and the seat after
+// the dealer leads. Once a deal is over the next dealer starts the following
+// one with a "deal" action. With three players the dealer sits out and scores
+// the game points of the deal's winner.
+type Match struct {
+	Goal int
+	// Rules for every deal; DefaultRules when nil.
+	Rules *RuleSet
+}
+
+func (Match) Name() string { return "sixtysix-match" }
+
+func (m Match) InitialState(seed int64) any {
+	goal := m.Goal
+	if goal <= 0 {
+		goal = DefaultGoal
+	}
+	return newMatch(seed, goal, Game{Rules: m.Rules}.rules())
+}
+
+// InitialStateWith implements engine.Configurable: options is a JSON RuleSet
+// with an extra "goal" field.
+func (m Match) InitialStateWith(seed int64, options json.RawMessage) (any, error) {
+	base := m.InitialState(seed).(MatchState)
+	opts := struct {
+		Goal int `json:"goal"`
+		RuleSet
+	}{Goal: base.Goal, RuleSet: *base.Deal.Rules}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &opts); err != nil {
+			return nil, ErrInvalidRules.Errorf("sixtysix: invalid rules: %v", err)
+		}
+	}
+	if opts.Goal <= 0 {
+		return nil, ErrInvalidRules.Errorf("sixtysix: goal must be positive")
+	}
+	if err := opts.RuleSet.Validate(); err != nil {
+		return nil, err
+	}
+	return newMatch(seed, opts.Goal, opts.RuleSet), nil
+}
+
+// Options implements engine.OptionsReporter: the match's goal and rules.
+func (Match) Options(s any) (json.RawMessage, error) {
+	ms := s.(MatchState)
+	return json.Marshal(struct {
+		Goal int `json:"goal"`
+		RuleSet
+	}{ms.Goal, ms.Deal.rules()})
+}
+
+func newMatch(seed int64, goal int, rules RuleSet) MatchState {
+	return MatchState{
+		Deal:       newDeal(dealSeed(seed, 1), rules.Players-1, rules),
+		DealNumber: 1,
+		Dealer:     rules.Players - 1,
+		GamePoints: make([]int, rules.Players),
+		Goal:       goal,
+		Results:    []Outcome{},
+		Winner:     -1,
+		Seed:       seed,
+	}
+}
+
+func (Match) Validate(s any, a engine.Action) error {
+	ms := s.(MatchState)
+	if ms.Winner != -1 {
+		return ErrMatchOver
+	}
+	if a.Type == ActionDeal {
+		if !ms.Deal.DealOver {
+			return ErrDealInProgress
+		}
+		return nil
+	}
+	if ms.Deal.DealOver {
+		return ErrAwaitingDeal
+	}
+	return Game{}.Validate(ms.Deal, a)
+}
+
+func (Match) Apply(s any, a engine.Action) (any, error) {
+	ms := s.(MatchState)
+	ms.Results = append([]Outcome(nil), ms.Results...)
+	ms.GamePoints = slices.Clone(ms.GamePoints)
+	if a.Type == ActionDeal {
+		ms.DealNumber++
+		ms.Dealer = ms.nextDealer()
+		ms.Deal = newDeal(dealSeed(ms.Seed, ms.DealNumber), ms.Dealer, ms.Deal.rules())
+		return ms, nil
+	}
+	ns, err := Game{}.Apply(ms.Deal, a)
+	if err != nil {
+		return s, err
+	}
+	ms.Deal = ns.(State)
+	if o := ms.Deal.Outcome; o != nil {
+		ms.Results = append(ms.Results, *o)
+		ms.GamePoints[o.Winner] += o.GamePoints
+		if len(ms.GamePoints) == 3 {
+			ms.GamePoints[ms.Deal.SitOut] += o.GamePoints
+		}
+		// the deal's winner takes the match ahead of a dealer reaching the
+		// goal on the same deal
+		switch {
+		case ms.GamePoints[o.Winner] >= ms.Goal:
+			ms.Winner = o.Winner
+		case len(ms.GamePoints) == 3 && ms.GamePoints[ms.Deal.SitOut] >= ms.Goal:
+			ms.Winner = ms.Deal.SitOut
+		}
+	}
+	return ms, nil
+}
+
+// LegalActions implements engine.ActionLister.
+func (Match) LegalActions(s any) []engine.Action {
+	ms := s.(MatchState)
+	switch {
+	case ms.Winner != -1:
+		return nil
+	case ms.Deal.DealOver:
+		return []engine.Action{{Type: ActionDeal}}
+	default:
+		return Game{}.LegalActions(ms.Deal)
+	}
+}
+
+// Seats implements engine.TurnBased.
+func (Match) Seats(s any) int { return len(s.(MatchState).GamePoints) }
+
+// ToMove implements engine.TurnBased. Between deals the next dealer is to move.
+func (Match) ToMove(s any) int {
+	ms := s.(MatchState)
+	switch {
+	case ms.Winner != -1:
+		return -1
+	case ms.Deal.DealOver:
+		return ms.nextDealer()
+	default:
+		return ms.Deal.Current
+	}
+}
+
+// Finished implements engine.Finisher.
+func (Match) Finished(s any) bool { return s.(MatchState).Winner != -1 }
+
+// ViewFor implements engine.Viewer.
+func (Match) ViewFor(s any, seat int) any {
+	ms := s.(MatchState)
+	return MatchView{
+		Deal:       Game{}.ViewFor(ms.Deal, seat).(PlayerView),
+		DealNumber: ms.DealNumber,
+		Dealer:     ms.Dealer,
+		GamePoints: slices.Clone(ms.GamePoints),
+		Goal:       ms.Goal,
+		Results:    ms.Results,
+		Winner:     ms.Winner,
+	}
+}
+
+// nextDealer is the seat that deals after the current dealer.
+func (ms MatchState) nextDealer() int { return (ms.Dealer + 1) % len(ms.GamePoints) }
+
+// dealSeed derives the shuffle seed of deal n (1-based) from the match seed.
+func dealSeed(seed int64, n int) int64 {
+	return seed ^ int64(uint64(n)*0x9E3779B97F4A7C15)
+}
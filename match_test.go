@@ -0,0 +1,117 @@
+package sixtysix
+
+import (
+	"slices"
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+// lastTrick returns a deal with one trick left that seat 1 wins on the last
+// trick with 21 card points.
+func lastTrick() State {
+	return State{Current: 0, Hands: [][]int{{card(Hearts, 0)}, {card(Hearts, 11)}}, Closed: true, ClosedBy: -1, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
+}
+
+func TestMatchDealsAndTallies(t *testing.T) {
+	m := Match{}
+	ms := m.InitialState(42).(MatchState)
+	if ms.Goal != DefaultGoal || ms.Dealer != 1 || ms.Deal.Current != 0 || ms.Winner != -1 {
+		t.Fatalf("unexpected initial match: %+v", ms)
+	}
+	if err := m.Validate(ms, engine.Action{Type: ActionDeal}); err == nil {
+		t.Fatalf("expected deal to be rejected mid-deal")
+	}
+	first := ms.Deal
+
+	ms.Deal = lastTrick()
+	ns, _ := m.Apply(ms, actionPlay(card(Hearts, 0)))
+	ns, _ = m.Apply(ns, actionPlay(card(Hearts, 11)))
+	ms = ns.(MatchState)
+	if len(ms.Results) != 1 || !slices.Equal(ms.GamePoints, []int{0, 3}) {
+		t.Fatalf("expected 3 game points to seat 1, got %v %+v", ms.GamePoints, ms.Results)
+	}
+	if m.ToMove(ms) != 0 || m.Validate(ms, actionPlay(card(Hearts, 0))) == nil {
+		t.Fatalf("expected next dealer to be asked to deal")
+	}
+	if la := m.LegalActions(ms); len(la) != 1 || la[0].Type != ActionDeal {
+		t.Fatalf("expected only deal to be legal, got %+v", la)
+	}
+
+	ns, err := m.Apply(ms, engine.Action{Type: ActionDeal})
+	if err != nil {
+		t.Fatalf("deal: %v", err)
+	}
+	ms = ns.(MatchState)
+	if ms.DealNumber != 2 || ms.Dealer != 0 || ms.Deal.Current != 1 || ms.Deal.DealOver {
+		t.Fatalf("expected fresh deal led by seat 1, got %+v", ms)
+	}
+	if slices.Equal(ms.Deal.Hands[0], first.Hands[0]) {
+		t.Fatalf("expected a differently shuffled deal")
+	}
+	again := m.InitialState(42).(MatchState)
+	again.Deal.DealOver = true
+	ns, _ = m.Apply(again, engine.Action{Type: ActionDeal})
+	if !slices.Equal(ns.(MatchState).Deal.Hands[1], ms.Deal.Hands[1]) {
+		t.Fatalf("expected deals to be derived deterministically from the seed")
+	}
+}
+
+func TestMatchEndsAtGoal(t *testing.T) {
+	m := Match{Goal: 3}
+	ms := m.InitialState(1).(MatchState)
+	ms.Deal = lastTrick()
+	ns, _ := m.Apply(ms, actionPlay(card(Hearts, 0)))
+	ns, _ = m.Apply(ns, actionPlay(card(Hearts, 11)))
+	ms = ns.(MatchState)
+	if ms.Winner != 1 || !m.Finished(ms) || m.ToMove(ms) != -1 {
+		t.Fatalf("expected seat 1 to win the match, got %+v", ms)
+	}
+	if err := m.Validate(ms, engine.Action{Type: ActionDeal}); err == nil {
+		t.Fatalf("expected no further deals")
+	}
+	v := m.ViewFor(ms, 0).(MatchView)
+	if v.Winner != 1 || v.Deal.Seat != 0 {
+		t.Fatalf("unexpected view: %+v", v)
+	}
+}
+
+func TestThreePlayerMatchRotatesDealer(t *testing.T) {
+	rules := DefaultRules()
+	rules.Players = 3
+	m := Match{Rules: &rules}
+	ms := m.InitialState(3).(MatchState)
+	if m.Seats(ms) != 3 || ms.Dealer != 2 || ms.Deal.SitOut != 2 || ms.Deal.Current != 0 {
+		t.Fatalf("unexpected initial match: %+v", ms)
+	}
+
+	ms.Deal = lastTrick()
+	ms.Deal.Hands = append(ms.Deal.Hands, []int{})
+	ms.Deal.SitOut = 2
+	ms.Deal.Rules = &rules
+	ns, _ := m.Apply(ms, actionPlay(card(Hearts, 0)))
+	ns, _ = m.Apply(ns, actionPlay(card(Hearts, 11)))
+	ms = ns.(MatchState)
+	if !slices.Equal(ms.GamePoints, []int{0, 3, 3}) {
+		t.Fatalf("expected the dealer to score with the winner, got %v", ms.GamePoints)
+	}
+	if m.ToMove(ms) != 0 {
+		t.Fatalf("expected seat 0 to deal next, got %d", m.ToMove(ms))
+	}
+	ns, _ = m.Apply(ms, engine.Action{Type: ActionDeal})
+	ms = ns.(MatchState)
+	if ms.Dealer != 0 || ms.Deal.SitOut != 0 || ms.Deal.Current != 1 || len(ms.Deal.Hands[0]) != 0 {
+		t.Fatalf("expected seat 0 to sit out and seat 1 to lead, got dealer=%d current=%d", ms.Dealer, ms.Deal.Current)
+	}
+	ms.GamePoints = []int{6, 5, 6}
+	ms.Deal = lastTrick()
+	ms.Deal.Hands = [][]int{{}, {card(Hearts, 0)}, {card(Hearts, 11)}}
+	ms.Deal.Current = 1
+	ms.Deal.SitOut = 0
+	ms.Deal.Rules = &rules
+	ns, _ = m.Apply(ms, actionPlay(card(Hearts, 0)))
+	ns, _ = m.Apply(ns, actionPlay(card(Hearts, 11)))
+	if got := ns.(MatchState); got.Winner != 2 {
+		t.Fatalf("expected the deal's winner to take the match, got %d (%v)", got.Winner, got.GamePoints)
+	}
+}
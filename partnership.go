@@ -0,0 +1,290 @@
+package sixtysix
+
+import (
+	"math/rand"
+	"slices"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+// ReasonTied ends a partnership deal in which both teams took 65.
+const ReasonTied = "tied"
+
+// PartnershipState is a deal of four-player partnership Sixty-six. Seats 0 and
+// 2 play against seats 1 and 3; per-team fields are indexed by seat%2.
+type PartnershipState struct {
+	Current   int     `json:"current"`
+	Dealer    int     `json:"dealer"`
+	Hands     [][]int `json:"hands"`
+	TrumpSuit int     `json:"trumpSuit"`
+	// TrumpCard is the dealer's last card, shown to everyone to fix trumps.
+	TrumpCard int   `json:"trumpCard"`
+	Trick     []int `json:"trick"`
+	Leader    int   `json:"leader"` // seat that led the current trick
+
+	Scores          []int   `json:"scores"`
+	Won             [][]int `json:"won"`
+	Tricks          []int   `json:"tricks"`
+	LastTrick       []int   `json:"lastTrick"`
+	LastTrickWinner int     `json:"lastTrickWinner"`
+
+	DealOver bool `json:"dealOver"`
+	// Outcome.Winner is the winning team, or -1 for a tie.
+	Outcome *Outcome `json:"outcome,omitempty"`
+}
+
+// PartnershipView is the part of a PartnershipState visible to a single seat.
+type PartnershipView struct {
+	Seat            int      `json:"seat"`
+	Team            int      `json:"team"` // -1 for spectators
+	Current         int      `json:"current"`
+	Dealer          int      `json:"dealer"`
+	Hand            []int    `json:"hand"`
+	HandSizes       []int    `json:"handSizes"`
+	TrumpSuit       int      `json:"trumpSuit"`
+	TrumpCard       int      `json:"trumpCard"`
+	Trick           []int    `json:"trick"`
+	Leader          int      `json:"leader"`
+	Scores          []int    `json:"scores"`
+	Won             []int    `json:"won"` // own team's captured cards
+	Tricks          []int    `json:"tricks"`
+	LastTrick       []int    `json:"lastTrick"`
+	LastTrickWinner int      `json:"lastTrickWinner"`
+	DealOver        bool     `json:"dealOver"`
+	Outcome         *Outcome `json:"outcome,omitempty"`
+}
+
+// Partnership is four-player partnership Sixty-six: 32 cards, eight each and
+// no stock. Partners pool their card points; the team with 66 wins the deal.
+type Partnership struct{}
+
+const (
+	partnershipSeats = 4
+	partnershipDeck  = 32
+	partnershipBonus = 10
+	partnershipGoal  = 66
+)
+
+func (Partnership) Name() string { return "sixtysix-partnership" }
+
+func (Partnership) InitialState(seed int64) any {
+	return newPartnershipDeal(seed, partnershipSeats-1)
+}
+
+// newPartnershipDeal deals four cards at a time starting left of dealer; the
+// dealer's last card fixes trumps and the seat after the dealer leads.
+func newPartnershipDeal(seed int64, dealer int) PartnershipState {
+	r := rand.New(rand.NewSource(seed))
+	deck := newDeck(partnershipDeck)
+	r.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+	hands := make([][]int, partnershipSeats)
+	for round := 0; round < 2; round++ {
+		for i := 1; i <= partnershipSeats; i++ {
+			p := (dealer + i) % partnershipSeats
+			hands[p] = append(hands[p], deck[:4]...)
+			deck = deck[4:]
+		}
+	}
+	trumpCard := hands[dealer][len(hands[dealer])-1]
+	for _, h := range hands {
+		sortHand(h)
+	}
+	leader := (dealer + 1) % partnershipSeats
+	return PartnershipState{
+		Current:         leader,
+		Dealer:          dealer,
+		Hands:           hands,
+		TrumpSuit:       cardSuit(trumpCard),
+		TrumpCard:       trumpCard,
+		Leader:          leader,
+		Scores:          make([]int, 2),
+		Won:             make([][]int, 2),
+		Tricks:          make([]int, 2),
+		LastTrickWinner: -1,
+	}
+}
+
+func (Partnership) Validate(s any, a engine.Action) error {
+	st := s.(PartnershipState)
+	if st.DealOver {
+		return ErrDealOver
+	}
+	if a.Type != ActionPlay {
+		return ErrUnknownAction
+	}
+	c, err := getCard(a.Payload, "card")
+	if err != nil {
+		return err
+	}
+	hand := st.Hands[st.Current]
+	if !contains(hand, c) {
+		return ErrCardNotInHand.With("card", Card(c))
+	}
+	if len(st.Trick) == 0 {
+		return nil
+	}
+	return partnershipFollowError(hand, st.Trick, c, st.TrumpSuit)
+}
+
+func (Partnership) Apply(s any, a engine.Action) (any, error) {
+	st := s.(PartnershipState).clone()
+	if a.Type != ActionPlay {
+		return s, ErrUnknownAction
+	}
+	c, _ := getCard(a.Payload, "card")
+	st.Hands[st.Current] = remove(st.Hands[st.Current], c)
+	st.Trick = append(st.Trick, c)
+	if len(st.Trick) < partnershipSeats {
+		st.Current = (st.Current + 1) % partnershipSeats
+		return st, nil
+	}
+	winner := (st.Leader + trickWinner(st.Trick, st.TrumpSuit)) % partnershipSeats
+	team := winner % 2
+	for _, x := range st.Trick {
+		st.Scores[team] += trickPoints(x)
+	}
+	st.Won[team] = append(st.Won[team], st.Trick...)
+	st.Tricks[team]++
+	st.LastTrick = st.Trick
+	st.LastTrickWinner = winner
+	st.Trick = nil
+	st.Current, st.Leader = winner, winner
+	if len(st.Hands[winner]) == 0 {
+		st.Scores[team] += partnershipBonus
+		st.end()
+	}
+	return st, nil
+}
+
+// end scores the finished deal for the team that took 66.
+func (st *PartnershipState) end() {
+	st.DealOver = true
+	winner := 0
+	switch {
+	case st.Scores[1] > st.Scores[0]:
+		winner = 1
+	case st.Scores[1] == st.Scores[0]:
+		st.Outcome = &Outcome{Winner: -1, Team: true, Reason: ReasonTied}
+		return
+	}
+	loser := 1 - winner
+	st.Outcome = &Outcome{
+		Winner:     winner,
+		Team:       true,
+		GamePoints: gamePoints(st.Scores[loser], st.Tricks[loser], partnershipGoal),
+		Reason:     ReasonReached66,
+	}
+}
+
+// partnershipFollowError checks the duties of every seat after the lead:
+// follow suit and beat the winning card if possible; when void, trump, and
+// overtrump a trump that is winning if possible.
+func partnershipFollowError(hand, trick []int, c, trump int) error {
+	ls := cardSuit(trick[0])
+	w := trick[trickWinner(trick, trump)]
+	canBeat := func(suit int) bool {
+		for _, x := range hand {
+			if cardSuit(x) == suit && beats(x, w, trump) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case cardSuit(c) != ls && hasSuit(hand, ls):
+		return ErrMustFollowSuit.With("card", Card(c))
+	case cardSuit(c) == ls:
+		if !beats(c, w, trump) && canBeat(ls) {
+			return ErrMustHeadTrick.With("card", Card(c))
+		}
+	case cardSuit(c) != trump && hasSuit(hand, trump):
+		return ErrMustTrump.With("card", Card(c))
+	case cardSuit(c) == trump && !beats(c, w, trump) && canBeat(trump):
+		return ErrMustOvertrump.With("card", Card(c))
+	}
+	return nil
+}
+
+// LegalActions implements engine.ActionLister.
+func (g Partnership) LegalActions(s any) []engine.Action {
+	st := s.(PartnershipState)
+	if st.DealOver {
+		return nil
+	}
+	var out []engine.Action
+	for _, c := range st.Hands[st.Current] {
+		a := engine.Action{Type: ActionPlay, Payload: map[string]any{"card": c}}
+		if g.Validate(st, a) == nil {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Seats implements engine.TurnBased.
+func (Partnership) Seats(any) int { return partnershipSeats }
+
+// ToMove implements engine.TurnBased.
+func (Partnership) ToMove(s any) int {
+	st := s.(PartnershipState)
+	if st.DealOver {
+		return -1
+	}
+	return st.Current
+}
+
+// Finished implements engine.Finisher.
+func (Partnership) Finished(s any) bool { return s.(PartnershipState).DealOver }
+
+// ViewFor implements engine.Viewer. Seats outside 0-3 get a spectator view.
+func (Partnership) ViewFor(s any, seat int) any {
+	st := s.(PartnershipState)
+	v := PartnershipView{
+		Seat:            seat,
+		Team:            -1,
+		Current:         st.Current,
+		Dealer:          st.Dealer,
+		Hand:            []int{},
+		HandSizes:       make([]int, len(st.Hands)),
+		TrumpSuit:       st.TrumpSuit,
+		TrumpCard:       st.TrumpCard,
+		Trick:           append([]int{}, st.Trick...),
+		Leader:          st.Leader,
+		Scores:          slices.Clone(st.Scores),
+		Won:             []int{},
+		Tricks:          slices.Clone(st.Tricks),
+		LastTrick:       append([]int{}, st.LastTrick...),
+		LastTrickWinner: st.LastTrickWinner,
+		DealOver:        st.DealOver,
+		Outcome:         st.Outcome,
+	}
+	for i, h := range st.Hands {
+		v.HandSizes[i] = len(h)
+	}
+	if seat >= 0 && seat < partnershipSeats {
+		v.Team = seat % 2
+		v.Hand = append(v.Hand, st.Hands[seat]...)
+		v.Won = append(v.Won, st.Won[seat%2]...)
+	}
+	return v
+}
+
+// clone returns a copy of st that shares no slices with it.
+func (st PartnershipState) clone() PartnershipState {
+	hands := make([][]int, len(st.Hands))
+	for i, h := range st.Hands {
+		hands[i] = slices.Clone(h)
+	}
+	won := make([][]int, 2)
+	for i := range won {
+		if i < len(st.Won) {
+			won[i] = slices.Clone(st.Won[i])
+		}
+	}
+	st.Hands, st.Won = hands, won
+	st.Scores = seatInts(st.Scores, 2)
+	st.Tricks = seatInts(st.Tricks, 2)
+	st.Trick = slices.Clone(st.Trick)
+	st.LastTrick = slices.Clone(st.LastTrick)
+	return st
+}
@@ -0,0 +1,106 @@
+package sixtysix
+
+import (
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+func TestPartnershipDeal(t *testing.T) {
+	g := Partnership{}
+	st := g.InitialState(11).(PartnershipState)
+	seen := map[int]bool{}
+	for _, h := range st.Hands {
+		if len(h) != 8 {
+			t.Fatalf("expected eight cards each, got %v", st.Hands)
+		}
+		for _, c := range h {
+			seen[c] = true
+		}
+	}
+	if len(seen) != 32 || !seen[card(Hearts, 7)] || !seen[card(Spades, 8)] {
+		t.Fatalf("expected the 32-card deck to be dealt, got %d cards", len(seen))
+	}
+	if !contains(st.Hands[st.Dealer], st.TrumpCard) || st.TrumpSuit != cardSuit(st.TrumpCard) || st.Current != 0 {
+		t.Fatalf("unexpected trump or leader: %+v", st)
+	}
+	v := g.ViewFor(st, 1).(PartnershipView)
+	if v.Team != 1 || len(v.Hand) != 8 || v.HandSizes[0] != 8 {
+		t.Fatalf("unexpected view: %+v", v)
+	}
+	if v := g.ViewFor(st, 4).(PartnershipView); v.Team != -1 || len(v.Hand) != 0 {
+		t.Fatalf("expected a spectator view: %+v", v)
+	}
+}
+
+func TestPartnershipFollowRules(t *testing.T) {
+	g := Partnership{}
+	st := PartnershipState{
+		Current:   2,
+		Leader:    0,
+		TrumpSuit: Spades,
+		Trick:     []int{card(Hearts, 4), card(Spades, 7)},
+		Hands:     [][]int{{}, {}, {card(Clubs, 11), card(Spades, 8), card(Spades, 0)}, {}},
+	}
+	if err := g.Validate(st, actionPlay(card(Clubs, 11))); err == nil || err.Error() != "must trump when unable to follow suit" {
+		t.Fatalf("expected trump duty, got %v", err)
+	}
+	if err := g.Validate(st, actionPlay(card(Spades, 8))); err != nil {
+		t.Fatalf("overtrump: %v", err)
+	}
+	st.Hands[2] = []int{card(Hearts, 3), card(Hearts, 10)}
+	if err := g.Validate(st, actionPlay(card(Hearts, 3))); err != nil {
+		t.Fatalf("a trumped trick need not be headed: %v", err)
+	}
+	st.Trick = []int{card(Hearts, 4)}
+	st.Current = 1
+	st.Hands[1] = []int{card(Hearts, 3), card(Hearts, 10)}
+	if err := g.Validate(st, actionPlay(card(Hearts, 3))); err == nil || err.Error() != "must head the trick with a higher card" {
+		t.Fatalf("expected heading duty, got %v", err)
+	}
+}
+
+func TestPartnershipPlaysOutForTeams(t *testing.T) {
+	g := Partnership{}
+	for seed := int64(0); seed < 10; seed++ {
+		var st any = g.InitialState(seed)
+		for !g.Finished(st) {
+			la := g.LegalActions(st)
+			if len(la) == 0 {
+				t.Fatalf("seed %d: no legal play: %+v", seed, st)
+			}
+			var err error
+			if st, err = g.Apply(st, la[0]); err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+		}
+		ps := st.(PartnershipState)
+		if ps.Scores[0]+ps.Scores[1] != 130 || ps.Tricks[0]+ps.Tricks[1] != 8 {
+			t.Fatalf("seed %d: unexpected totals: scores %v tricks %v", seed, ps.Scores, ps.Tricks)
+		}
+		o := ps.Outcome
+		switch {
+		case !o.Team:
+			t.Fatalf("seed %d: outcome not marked as a team win: %+v", seed, o)
+		case o.Reason == ReasonTied:
+			if ps.Scores[0] != 65 {
+				t.Fatalf("seed %d: unexpected tie %v", seed, ps.Scores)
+			}
+		case ps.Scores[o.Winner] < 66 || o.GamePoints < 1:
+			t.Fatalf("seed %d: unexpected outcome %+v for %v", seed, o, ps.Scores)
+		}
+	}
+}
+
+func TestTrickWinnerOrdersEightsAndSevens(t *testing.T) {
+	trick := []int{card(Hearts, 7), card(Hearts, 8), card(Hearts, 0), card(Clubs, 11)}
+	if w := trickWinner(trick, Spades); w != 2 {
+		t.Fatalf("expected the nine to win, got index %d", w)
+	}
+	if trickPoints(card(Hearts, 8)) != 0 || trickPoints(card(Hearts, 7)) != 0 {
+		t.Fatalf("eights and sevens score nothing")
+	}
+	if _, err := (Partnership{}).Apply(PartnershipState{}, engine.Action{Type: ActionDeclare}); err == nil {
+		t.Fatalf("expected declare to be unknown in the partnership game")
+	}
+}
@@ -0,0 +1,111 @@
+package sixtysix
+
+import "encoding/json"
+
+// RuleSet configures a deal. It is stored in State so that a deal keeps
+// following the rules it was dealt with.
+type RuleSet struct {
+	// Players is 2, or 3 for the variant in which the dealer sits out.
+	Players int `json:"players"`
+	// Target is the number of card points needed to win a deal (66).
+	Target int `json:"target"`
+	// LastTrickBonus is added to the winner of the final trick (10) unless the
+	// stock was closed.
+	LastTrickBonus int `json:"lastTrickBonus"`
+	// MarriagePoints and TrumpMarriagePoints score a declared king and queen
+	// of a plain suit (20) and of trumps (40).
+	MarriagePoints      int `json:"marriagePoints"`
+	TrumpMarriagePoints int `json:"trumpMarriagePoints"`
+	// HandSize is the number of cards dealt to each seat (6).
+	HandSize int `json:"handSize"`
+	// DeckSize is 24, or 20 to play without nines; the lowest trump is then
+	// the jack, which takes the nine's place in the trump exchange.
+	DeckSize int `json:"deckSize"`
+	// ClosePenalty is what a failing closer concedes: this many game points,
+	// one more if the opponent had no trick at the close (2). Zero scores a
+	// failed close like any other lost deal.
+	ClosePenalty int `json:"closePenalty"`
+	// AutoWin ends the deal as soon as a seat reaches Target instead of
+	// requiring it to announce66.
+	AutoWin bool `json:"autoWin"`
+	// LenientFollow only requires following suit once the stock is closed or
+	// exhausted, dropping the duties to head the trick and to trump.
+	LenientFollow bool `json:"lenientFollow"`
+	// LenientStock allows exchanging the lowest trump before winning a trick
+	// and exchanging or closing when only two cards (one face down plus the
+	// trump card) remain.
+	LenientStock bool `json:"lenientStock"`
+}
+
+// DefaultRules returns traditional two-player Sixty-six.
+func DefaultRules() RuleSet {
+	return RuleSet{
+		Players:             2,
+		Target:              66,
+		LastTrickBonus:      10,
+		MarriagePoints:      20,
+		TrumpMarriagePoints: 40,
+		HandSize:            6,
+		DeckSize:            24,
+		ClosePenalty:        2,
+	}
+}
+
+// ParseRules overlays the JSON object raw on base; fields it omits keep their
+// value from base. The result is validated.
+func ParseRules(base RuleSet, raw json.RawMessage) (RuleSet, error) {
+	r := base
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return RuleSet{}, ErrInvalidRules.Errorf("sixtysix: invalid rules: %v", err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return RuleSet{}, err
+	}
+	return r, nil
+}
+
+// Validate reports rule combinations that cannot be dealt or won, as
+// ErrInvalidRules with a specific message.
+func (r RuleSet) Validate() error {
+	switch {
+	case r.Players != 2 && r.Players != 3:
+		return ErrInvalidRules.Errorf("sixtysix: players must be 2 or 3")
+	case r.DeckSize != 24 && r.DeckSize != 20:
+		return ErrInvalidRules.Errorf("sixtysix: deckSize must be 20 or 24")
+	case r.HandSize < 3 || 2*r.HandSize > r.DeckSize-2:
+		return ErrInvalidRules.Errorf("sixtysix: handSize must be between 3 and %d", (r.DeckSize-2)/2)
+	case r.Target <= 0:
+		return ErrInvalidRules.Errorf("sixtysix: target must be positive")
+	case r.LastTrickBonus < 0 || r.MarriagePoints < 0 || r.TrumpMarriagePoints < 0 || r.ClosePenalty < 0:
+		return ErrInvalidRules.Errorf("sixtysix: points must not be negative")
+	}
+	return nil
+}
+
+// exchangeRank is the rank value of the trump that may be exchanged for the
+// trump card: the nine, or the jack in a deck without nines.
+func (r RuleSet) exchangeRank() int {
+	if r.DeckSize == 20 {
+		return 2
+	}
+	return 0
+}
+
+// marriagePoints scores a marriage in suit.
+func (r RuleSet) marriagePoints(suit, trump int) int {
+	if suit == trump {
+		return r.TrumpMarriagePoints
+	}
+	return r.MarriagePoints
+}
+
+// rules returns the rules a state was dealt with; states built by hand
+// without rules play by DefaultRules.
+func (st State) rules() RuleSet {
+	if st.Rules == nil {
+		return DefaultRules()
+	}
+	return *st.Rules
+}
@@ -0,0 +1,53 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDefaultRulesValidate(t *testing.T) {
+	if err := DefaultRules().Validate(); err != nil {
+		t.Fatalf("default rules invalid: %v", err)
+	}
+	if (State{}).rules() != DefaultRules() {
+		t.Fatalf("state without rules should play by the defaults")
+	}
+}
+
+func TestParseRulesOverlaysBase(t *testing.T) {
+	r, err := ParseRules(DefaultRules(), json.RawMessage(`{"target":33,"autoWin":true}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if r.Target != 33 || !r.AutoWin || r.HandSize != 6 || r.TrumpMarriagePoints != 40 {
+		t.Fatalf("unexpected rules: %+v", r)
+	}
+	for _, raw := range []string{`{"deckSize":32}`, `{"handSize":12}`, `{"target":0}`, `{"marriagePoints":-20}`, `[1]`} {
+		if _, err := ParseRules(DefaultRules(), json.RawMessage(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestInitialStateWithDealsWithoutNines(t *testing.T) {
+	st, err := Game{}.InitialStateWith(5, json.RawMessage(`{"deckSize":20,"handSize":5}`))
+	if err != nil {
+		t.Fatalf("initial state: %v", err)
+	}
+	s := st.(State)
+	if len(s.Hands[0]) != 5 || len(s.Hands[1]) != 5 || len(s.Stock) != 9 {
+		t.Fatalf("unexpected deal: hands %d/%d stock %d", len(s.Hands[0]), len(s.Hands[1]), len(s.Stock))
+	}
+	for _, c := range append(append(append([]int{s.TrumpCard}, s.Hands[0]...), s.Hands[1]...), s.Stock...) {
+		if cardVal(c) == 0 {
+			t.Fatalf("nine %d dealt from a 20-card deck", c)
+		}
+	}
+	if s.Rules == nil || s.Rules.DeckSize != 20 {
+		t.Fatalf("rules not stored in state: %+v", s.Rules)
+	}
+	v := Game{}.ViewFor(s, 0).(PlayerView)
+	if v.Rules.DeckSize != 20 {
+		t.Fatalf("view does not carry rules: %+v", v.Rules)
+	}
+}
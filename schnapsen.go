@@ -0,0 +1,43 @@
+package sixtysix
+
+import "encoding/json"
+
+// SchnapsenRules returns Schnapsen: the 20-card member of the family, played
+// without nines, with five-card hands and no bonus for the last trick.
+func SchnapsenRules() RuleSet {
+	r := DefaultRules()
+	r.DeckSize = 20
+	r.HandSize = 5
+	r.LastTrickBonus = 0
+	return r
+}
+
+// Schnapsen is a single deal of Schnapsen. It plays by the same card model and
+// rules engine as Game; only the defaults differ.
+type Schnapsen struct {
+	Game
+}
+
+func (Schnapsen) Name() string { return "schnapsen" }
+
+func (s Schnapsen) InitialState(seed int64) any { return s.game().InitialState(seed) }
+
+// InitialStateWith implements engine.Configurable with SchnapsenRules as the
+// base for the overrides.
+func (s Schnapsen) InitialStateWith(seed int64, options json.RawMessage) (any, error) {
+	return s.game().InitialStateWith(seed, options)
+}
+
+// LoadState implements engine.StateLoader with SchnapsenRules for omitted
+// rules.
+func (s Schnapsen) LoadState(raw json.RawMessage) (any, error) {
+	return s.game().LoadState(raw)
+}
+
+func (s Schnapsen) game() Game {
+	if s.Rules == nil {
+		r := SchnapsenRules()
+		s.Rules = &r
+	}
+	return s.Game
+}
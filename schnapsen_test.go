@@ -0,0 +1,76 @@
+package sixtysix
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+func TestSchnapsenDeal(t *testing.T) {
+	g := Schnapsen{}
+	if g.Name() != "schnapsen" {
+		t.Fatalf("unexpected name %q", g.Name())
+	}
+	st := g.InitialState(9).(State)
+	if len(st.Hands[0]) != 5 || len(st.Hands[1]) != 5 || len(st.Stock) != 9 {
+		t.Fatalf("unexpected deal: hands %d/%d stock %d", len(st.Hands[0]), len(st.Hands[1]), len(st.Stock))
+	}
+	if st.rules() != SchnapsenRules() {
+		t.Fatalf("unexpected rules: %+v", st.Rules)
+	}
+	custom, err := g.InitialStateWith(9, json.RawMessage(`{"autoWin":true}`))
+	if err != nil {
+		t.Fatalf("initial state: %v", err)
+	}
+	if r := custom.(State).rules(); !r.AutoWin || r.DeckSize != 20 {
+		t.Fatalf("overrides should apply on top of Schnapsen rules: %+v", r)
+	}
+}
+
+func TestSchnapsenExchangesJackOfTrumps(t *testing.T) {
+	trump := 2
+	rules := SchnapsenRules()
+	st := State{
+		Current:   0,
+		Hands:     [][]int{{card(trump, 2), card(0, 11)}, {card(1, 10)}},
+		Stock:     []int{card(3, 3), card(3, 4)},
+		TrumpSuit: trump,
+		TrumpCard: card(trump, 11),
+		ClosedBy:  -1,
+		Tricks:    []int{1, 0},
+		Rules:     &rules,
+	}
+	g := Schnapsen{}
+	ns, err := g.Apply(st, engine.Action{Type: ActionExchange})
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	got := ns.(State)
+	if got.TrumpCard != card(trump, 2) || !contains(got.Hands[0], card(trump, 11)) {
+		t.Fatalf("jack not exchanged: trump card %d hand %v", got.TrumpCard, got.Hands[0])
+	}
+}
+
+func TestSchnapsenLastTrickHasNoBonus(t *testing.T) {
+	rules := SchnapsenRules()
+	st := State{
+		Current:   1,
+		Hands:     [][]int{{}, {card(0, 2)}},
+		TrumpSuit: 3,
+		TrumpCard: -1,
+		Trick:     []int{card(0, 11)},
+		ClosedBy:  -1,
+		Scores:    []int{40, 30},
+		Tricks:    []int{4, 4},
+		Rules:     &rules,
+	}
+	ns, err := Schnapsen{}.Apply(st, actionPlay(card(0, 2)))
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	got := ns.(State)
+	if got.Scores[0] != 53 || got.Outcome == nil || got.Outcome.Winner != 0 || got.Outcome.Reason != ReasonLastTrick {
+		t.Fatalf("unexpected end: scores %v outcome %+v", got.Scores, got.Outcome)
+	}
+}
@@ -1,7 +1,7 @@
 package sixtysix
 
 import (
-	"errors"
+	"encoding/json"
 	"math/rand"
 	"slices"
 
@@ -17,23 +17,126 @@ const (
 )
 
 var suits = []int{Clubs, Diamonds, Hearts, Spades}
-var rankOrder = []int{11, 10, 4, 3, 2, 0}
+
+// rankOrder lists rank values strongest first. A rank's value is its card
+// points, except for the pointless eight and seven of the 32-card deck, which
+// are encoded as 8 and 7.
+var rankOrder = []int{11, 10, 4, 3, 2, 0, 8, 7}
 
 func card(suit int, rankVal int) int { return suit*100 + rankVal }
 func cardSuit(c int) int             { return c / 100 }
 func cardVal(c int) int              { return c % 100 }
-func trickPoints(c int) int          { return cardVal(c) }
 
+func trickPoints(c int) int {
+	if v := cardVal(c); v != 8 && v != 7 {
+		return v
+	}
+	return 0
+}
+
+// strength orders cards of one suit: higher beats lower.
+func strength(c int) int { return len(rankOrder) - slices.Index(rankOrder, cardVal(c)) }
+
+// State is a single deal. Per-seat fields (Scores, Hands, Won, Tricks,
+// Pending) have one entry per seat at the table: two, or three when the dealer
+// sits out.
 type State struct {
 	Current   int      `json:"current"`
-	Scores    [2]int   `json:"scores"`
-	Hands     [2][]int `json:"hands"`
+	Scores    []int    `json:"scores"`
+	Hands     [][]int  `json:"hands"`
 	Stock     []int    `json:"stock"`
 	Closed    bool     `json:"closed"`
 	TrumpSuit int      `json:"trumpSuit"`
 	TrumpCard int      `json:"trumpCard"`
 	Trick     []int    `json:"trick"`
 	Winner    int      `json:"winner"`
+	DealOver  bool     `json:"dealOver"`
+	Outcome   *Outcome `json:"outcome,omitempty"`
+	Rules     *RuleSet `json:"rules,omitempty"`
+
+	// SitOut is the dealer who takes no part in a three-player deal (-1 in a
+	// two-player deal).
+	SitOut int `json:"sitOut"`
+
+	// ClosedBy is the seat that closed the stock (-1 if nobody did);
+	// OpponentPointsAtClose and OpponentTricksAtClose record the other seat's
+	// score and tricks at that moment, by which the deal is scored.
+	ClosedBy              int `json:"closedBy"`
+	OpponentPointsAtClose int `json:"opponentPointsAtClose"`
+	OpponentTricksAtClose int `json:"opponentTricksAtClose"`
+
+	// Won holds the cards each seat has captured and Tricks how many tricks
+	// that is. LastTrick keeps the most recently completed trick (lead first)
+	// so clients can show it after Trick clears.
+	Won             [][]int `json:"won"`
+	Tricks          []int   `json:"tricks"`
+	LastTrick       []int   `json:"lastTrick"`
+	LastTrickWinner int     `json:"lastTrickWinner"`
+
+	// Marriages lists every declaration so far. MustPlay holds the king and
+	// queen the declarer has to lead next (empty otherwise). Marriage points
+	// of a seat that has not yet won a trick wait in Pending.
+	Marriages []Marriage `json:"marriages"`
+	MustPlay  []int      `json:"mustPlay,omitempty"`
+	Pending   []int      `json:"pending"`
+}
+
+// Marriage is a declared king and queen of one suit.
+type Marriage struct {
+	Seat int `json:"seat"`
+	Suit int `json:"suit"`
+}
+
+// Outcome describes how a finished deal was won.
+type Outcome struct {
+	Winner int `json:"winner"`
+	// Team is set when Winner is a team rather than a seat, as in the
+	// partnership game: team 0 is seats 0 and 2, team 1 seats 1 and 3.
+	Team bool `json:"team,omitempty"`
+	// GamePoints awarded to the winner: 1, 2 if the loser has fewer than 33
+	// card points (schneider), 3 if the loser took no trick (schwarz). A
+	// closer who wins is scored by the loser's points and tricks when the
+	// stock was closed; a closer who loses concedes 2, or 3 if the winner had
+	// no trick when it was closed.
+	GamePoints int    `json:"gamePoints"`
+	Reason     string `json:"reason"`
+}
+
+// Reasons a deal ends.
+const (
+	ReasonReached66    = "reached66"    // winner reached 66 card points
+	ReasonLastTrick    = "lastTrick"    // nobody reached 66; the last trick decides
+	ReasonCloserFailed = "closerFailed" // the seat that closed the stock did not reach 66
+	ReasonAnnounced    = "announced66"  // winner announced 66 and had it
+	ReasonFalseClaim   = "falseClaim"   // the loser announced 66 without having it
+)
+
+// PlayerView is the part of a State visible to a single seat: its own hand,
+// public table information and counts for everything that is face down.
+type PlayerView struct {
+	Seat             int        `json:"seat"`
+	SitOut           int        `json:"sitOut"`
+	Current          int        `json:"current"`
+	Scores           []int      `json:"scores"`
+	Hand             []int      `json:"hand"`
+	OpponentHandSize int        `json:"opponentHandSize"`
+	StockCount       int        `json:"stockCount"`
+	Closed           bool       `json:"closed"`
+	ClosedBy         int        `json:"closedBy"`
+	TrumpSuit        int        `json:"trumpSuit"`
+	TrumpCard        int        `json:"trumpCard"` // -1 once the stock is closed or drawn
+	Trick            []int      `json:"trick"`
+	Won              []int      `json:"won"` // own captured cards
+	Tricks           []int      `json:"tricks"`
+	LastTrick        []int      `json:"lastTrick"`
+	LastTrickWinner  int        `json:"lastTrickWinner"`
+	Marriages        []Marriage `json:"marriages"`
+	MustPlay         []int      `json:"mustPlay,omitempty"`
+	Pending          []int      `json:"pending"`
+	Winner           int        `json:"winner"`
+	DealOver         bool       `json:"dealOver"`
+	Outcome          *Outcome   `json:"outcome,omitempty"`
+	Rules            RuleSet    `json:"rules"`
 }
 
 const (
@@ -42,164 +145,443 @@ const (
 	ActionCloseStock = "closeStock"
 	ActionDeclare    = "declare"
 	ActionExchange   = "exchangeTrump"
+	ActionAnnounce   = "announce66"
 )
 
-type Game struct{}
+// Game is a single two-player deal of Sixty-six.
+type Game struct {
+	// Rules used for new sessions; DefaultRules when nil.
+	Rules *RuleSet
+}
 
 func (Game) Name() string { return "sixtysix" }
 
-func (Game) InitialState(seed int64) any {
+func (g Game) InitialState(seed int64) any {
+	rules := g.rules()
+	return newDeal(seed, rules.Players-1, rules)
+}
+
+// InitialStateWith implements engine.Configurable: options is a JSON RuleSet
+// whose fields override the game's rules.
+func (g Game) InitialStateWith(seed int64, options json.RawMessage) (any, error) {
+	rules, err := ParseRules(g.rules(), options)
+	if err != nil {
+		return nil, err
+	}
+	return newDeal(seed, rules.Players-1, rules), nil
+}
+
+// Options implements engine.OptionsReporter: the complete RuleSet of the deal,
+// so that replays do not depend on the game's or the default rules.
+func (g Game) Options(s any) (json.RawMessage, error) {
+	return json.Marshal(s.(State).rules())
+}
+
+func (g Game) rules() RuleSet {
+	if g.Rules == nil {
+		return DefaultRules()
+	}
+	return *g.Rules
+}
+
+// newDeal shuffles and deals a fresh deal. The seat after dealer receives
+// cards first and leads; with three players the dealer sits out.
+func newDeal(seed int64, dealer int, rules RuleSet) State {
+	n := rules.Players
+	leader, sitOut := (dealer+1)%n, -1
+	if n == 3 {
+		sitOut = dealer
+	}
 	r := rand.New(rand.NewSource(seed))
-	deck := newDeck()
+	deck := newDeck(rules.DeckSize)
 	r.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
 	trumpCard := deck[len(deck)-1]
 	trumpSuit := cardSuit(trumpCard)
-	hands := [2][]int{{}, {}}
+	hands := make([][]int, n)
+	for i := range hands {
+		hands[i] = []int{}
+	}
 	deal := func(p, n int) {
 		for k := 0; k < n; k++ {
 			hands[p] = append(hands[p], deck[0])
 			deck = deck[1:]
 		}
 	}
-	deal(0, 3)
-	deal(1, 3)
-	deal(0, 3)
-	deal(1, 3)
+	follower := (leader + 1) % n
+	if follower == sitOut {
+		follower = (follower + 1) % n
+	}
+	deal(leader, 3)
+	deal(follower, 3)
+	deal(leader, rules.HandSize-3)
+	deal(follower, rules.HandSize-3)
 	stock := append([]int(nil), deck[:len(deck)-1]...)
-	st := State{Current: 0, Scores: [2]int{0, 0}, Hands: hands, Stock: stock, Closed: false, TrumpSuit: trumpSuit, TrumpCard: trumpCard, Winner: -1}
-	for i := 0; i < 2; i++ {
-		slices.SortFunc(st.Hands[i], func(a, b int) int {
-			if cardSuit(a) != cardSuit(b) {
-				return cardSuit(a) - cardSuit(b)
-			}
-			return cardVal(a) - cardVal(b)
-		})
+	st := State{Current: leader, Scores: make([]int, n), Hands: hands, Stock: stock, Closed: false, ClosedBy: -1, TrumpSuit: trumpSuit, TrumpCard: trumpCard, Winner: -1, Rules: &rules,
+		SitOut: sitOut, Won: make([][]int, n), Tricks: make([]int, n), Pending: make([]int, n)}
+	for i := range st.Hands {
+		sortHand(st.Hands[i])
 	}
 	return st
 }
 
+// sortHand orders a hand by suit, then from weakest to strongest.
+func sortHand(h []int) {
+	slices.SortFunc(h, func(a, b int) int {
+		if cardSuit(a) != cardSuit(b) {
+			return cardSuit(a) - cardSuit(b)
+		}
+		return strength(a) - strength(b)
+	})
+}
+
 func (Game) Validate(s any, a engine.Action) error {
-	st := s.(State)
-	if st.Winner != -1 {
-		return errors.New("game over")
+	// like Apply, treat per-seat fields missing from older states as zero
+	st := s.(State).clone()
+	rules := st.rules()
+	if st.DealOver {
+		return ErrDealOver
 	}
 	switch a.Type {
 	case ActionPlay:
-		c, ok := getInt(a.Payload, "card")
-		if !ok {
-			return errors.New("missing card")
+		c, err := getCard(a.Payload, "card")
+		if err != nil {
+			return err
 		}
 		if !contains(st.Hands[st.Current], c) {
-			return errors.New("card not in hand")
+			return ErrCardNotInHand.With("card", Card(c))
+		}
+		if len(st.MustPlay) > 0 && !contains(st.MustPlay, c) {
+			return ErrMustLeadMarriage.With("card", Card(c))
 		}
 		if len(st.Trick) == 1 && (st.Closed || len(st.Stock) == 0) {
-			lead := st.Trick[0]
-			ls := cardSuit(lead)
-			if cardSuit(c) != ls && hasSuit(st.Hands[st.Current], ls) {
-				return errors.New("must follow suit")
-			}
+			return followError(st.Hands[st.Current], st.Trick[0], c, st.TrumpSuit, rules.LenientFollow)
 		}
 		return nil
 	case ActionCloseStock:
 		if st.Closed || len(st.Stock) == 0 {
-			return errors.New("cannot close")
+			return ErrCannotClose
 		}
 		if len(st.Trick) != 0 {
-			return errors.New("cannot close mid-trick")
+			return ErrCloseNotAtLead
+		}
+		if !rules.LenientStock && len(st.Stock) < 2 {
+			return ErrCloseTooLate
 		}
 		return nil
 	case ActionDeclare:
 		suit, ok := getInt(a.Payload, "suit")
 		if !ok {
-			return errors.New("missing suit")
+			return ErrMissingSuit
 		}
 		k, q := card(suit, 4), card(suit, 3)
 		if !(contains(st.Hands[st.Current], k) && contains(st.Hands[st.Current], q)) {
-			return errors.New("no marriage")
+			return ErrNoMarriage.With("suit", suit)
 		}
 		if len(st.Trick) != 0 {
-			return errors.New("declare only on lead")
+			return ErrDeclareNotAtLead
+		}
+		if len(st.MustPlay) > 0 {
+			return ErrOneMarriagePerLead
+		}
+		for _, m := range st.Marriages {
+			if m.Suit == suit {
+				return ErrMarriageDeclared.With("suit", suit)
+			}
 		}
 		return nil
 	case ActionExchange:
 		if st.Closed || len(st.Stock) == 0 {
-			return errors.New("cannot exchange when stock closed or empty")
+			return ErrCannotExchange
 		}
 		if len(st.Trick) != 0 {
-			return errors.New("exchange only at lead")
+			return ErrExchangeNotAtLead
 		}
-		if !contains(st.Hands[st.Current], card(st.TrumpSuit, 0)) {
-			return errors.New("no nine of trump to exchange")
+		if !contains(st.Hands[st.Current], card(st.TrumpSuit, rules.exchangeRank())) {
+			return ErrNoExchangeTrump
+		}
+		if !rules.LenientStock {
+			if st.Tricks[st.Current] == 0 {
+				return ErrExchangeNeedsTrick
+			}
+			if len(st.Stock) < 2 {
+				return ErrExchangeTooLate
+			}
+		}
+		return nil
+	case ActionAnnounce:
+		if rules.AutoWin {
+			return ErrAutoWin
+		}
+		if len(st.Trick) != 0 {
+			return ErrAnnounceNotAtLead
 		}
 		return nil
 	default:
-		return errors.New("unknown action")
+		return ErrUnknownAction
 	}
 }
 
 func (Game) Apply(s any, a engine.Action) (any, error) {
-	st := s.(State)
+	st := s.(State).clone()
+	rules := st.rules()
 	switch a.Type {
 	case ActionPlay:
-		c, _ := getInt(a.Payload, "card")
+		c, _ := getCard(a.Payload, "card")
 		actor := st.Current
 		st.Hands[actor] = remove(st.Hands[actor], c)
 		st.Trick = append(st.Trick, c)
+		st.MustPlay = nil
 		if len(st.Trick) == 2 {
-			winner := trickWinner(st.Trick[0], st.Trick[1], st.TrumpSuit)
+			winner := st.opponent(actor) // the leader
+			if trickWinner(st.Trick, st.TrumpSuit) == 1 {
+				winner = actor
+			}
 			pts := trickPoints(st.Trick[0]) + trickPoints(st.Trick[1])
-			st.Scores[winner] += pts
-			st.Trick = st.Trick[:0]
+			st.Scores[winner] += pts + st.Pending[winner]
+			st.Pending[winner] = 0
+			st.Won[winner] = append(st.Won[winner], st.Trick...)
+			st.Tricks[winner]++
+			st.LastTrick = st.Trick
+			st.LastTrickWinner = winner
+			st.Trick = nil
 			if !st.Closed && len(st.Stock) > 0 {
 				if len(st.Stock) >= 2 {
 					st.Hands[winner] = append(st.Hands[winner], st.Stock[0])
-					st.Hands[1-winner] = append(st.Hands[1-winner], st.Stock[1])
+					st.Hands[st.opponent(winner)] = append(st.Hands[st.opponent(winner)], st.Stock[1])
 					st.Stock = st.Stock[2:]
 				} else {
+					// last face-down card: the loser takes the face-up trump
 					st.Hands[winner] = append(st.Hands[winner], st.Stock[0])
-					st.Stock = st.Stock[:0]
+					st.Hands[st.opponent(winner)] = append(st.Hands[st.opponent(winner)], st.TrumpCard)
+					st.Stock = nil
 				}
 			}
 			st.Current = winner
-			if len(st.Hands[0])+len(st.Hands[1]) == 0 {
-				st.Scores[winner] += 10
+			last := len(st.Hands[actor])+len(st.Hands[winner]) == 0
+			if last && st.ClosedBy < 0 {
+				st.Scores[winner] += rules.LastTrickBonus
 			}
-			if st.Scores[winner] >= 66 {
-				st.Winner = winner
+			switch {
+			case st.Scores[winner] >= rules.Target && (rules.AutoWin || last):
+				st.end(winner, ReasonReached66)
+			case last && st.ClosedBy >= 0:
+				st.end(st.opponent(st.ClosedBy), ReasonCloserFailed)
+			case last:
+				st.end(winner, ReasonLastTrick)
 			}
 		} else {
-			st.Current = 1 - actor
+			st.Current = st.opponent(actor)
 		}
 		return st, nil
 	case ActionCloseStock:
 		st.Closed = true
+		st.ClosedBy = st.Current
+		st.OpponentPointsAtClose = st.Scores[st.opponent(st.Current)]
+		st.OpponentTricksAtClose = st.Tricks[st.opponent(st.Current)]
 		return st, nil
 	case ActionDeclare:
 		suit, _ := getInt(a.Payload, "suit")
-		pts := 20
-		if suit == st.TrumpSuit {
-			pts = 40
+		pts := rules.marriagePoints(suit, st.TrumpSuit)
+		st.Marriages = append(st.Marriages, Marriage{Seat: st.Current, Suit: suit})
+		st.MustPlay = []int{card(suit, 4), card(suit, 3)}
+		if st.Tricks[st.Current] == 0 {
+			st.Pending[st.Current] += pts
+			return st, nil
 		}
 		st.Scores[st.Current] += pts
-		if st.Scores[st.Current] >= 66 {
-			st.Winner = st.Current
+		if rules.AutoWin && st.Scores[st.Current] >= rules.Target {
+			st.end(st.Current, ReasonReached66)
+		}
+		return st, nil
+	case ActionAnnounce:
+		// pending marriage points are already credited once the seat has a
+		// trick, so the score is all that counts
+		if st.Scores[st.Current] >= rules.Target {
+			st.end(st.Current, ReasonAnnounced)
+		} else {
+			st.end(st.opponent(st.Current), ReasonFalseClaim)
 		}
 		return st, nil
 	case ActionExchange:
-		nine := card(st.TrumpSuit, 0)
-		st.Hands[st.Current] = remove(st.Hands[st.Current], nine)
+		low := card(st.TrumpSuit, rules.exchangeRank())
+		st.Hands[st.Current] = remove(st.Hands[st.Current], low)
 		st.Hands[st.Current] = append(st.Hands[st.Current], st.TrumpCard)
-		st.TrumpCard = nine
+		st.TrumpCard = low
 		return st, nil
 	default:
-		return s, errors.New("unknown action")
+		return s, ErrUnknownAction
 	}
 }
 
-func newDeck() []int {
-	d := make([]int, 0, 24)
+// LegalActions enumerates every action the player to move may take. Candidates
+// are filtered through Validate, so the result always agrees with it.
+func (g Game) LegalActions(s any) []engine.Action {
+	st := s.(State)
+	if st.DealOver {
+		return nil
+	}
+	var candidates []engine.Action
+	for _, c := range st.Hands[st.Current] {
+		candidates = append(candidates, engine.Action{Type: ActionPlay, Payload: map[string]any{"card": c}})
+	}
+	for _, suit := range suits {
+		candidates = append(candidates, engine.Action{Type: ActionDeclare, Payload: map[string]any{"suit": suit}})
+	}
+	candidates = append(candidates, engine.Action{Type: ActionCloseStock}, engine.Action{Type: ActionExchange}, engine.Action{Type: ActionAnnounce})
+	out := make([]engine.Action, 0, len(candidates))
+	for _, a := range candidates {
+		if g.Validate(st, a) == nil {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Seats implements engine.TurnBased: two, or three when the dealer sits out.
+func (Game) Seats(s any) int { return len(s.(State).Hands) }
+
+// ToMove implements engine.TurnBased.
+func (Game) ToMove(s any) int {
+	st := s.(State)
+	if st.DealOver {
+		return -1
+	}
+	return st.Current
+}
+
+// Finished implements engine.Finisher.
+func (Game) Finished(s any) bool { return s.(State).DealOver }
+
+// ViewFor redacts the state for the given seat. Seats not taking part in the
+// deal get a spectator view with no hand.
+func (Game) ViewFor(s any, seat int) any {
+	// clone pads per-seat fields missing from states saved before they existed
+	st := s.(State).clone()
+	v := PlayerView{
+		Seat:            seat,
+		SitOut:          st.SitOut,
+		Current:         st.Current,
+		Scores:          st.Scores,
+		Hand:            []int{},
+		StockCount:      len(st.Stock),
+		Closed:          st.Closed,
+		ClosedBy:        st.ClosedBy,
+		TrumpSuit:       st.TrumpSuit,
+		TrumpCard:       st.TrumpCard,
+		Trick:           append([]int{}, st.Trick...),
+		Won:             []int{},
+		Tricks:          st.Tricks,
+		LastTrick:       append([]int{}, st.LastTrick...),
+		LastTrickWinner: st.LastTrickWinner,
+		Marriages:       append([]Marriage{}, st.Marriages...),
+		MustPlay:        st.MustPlay,
+		Pending:         st.Pending,
+		Winner:          st.Winner,
+		DealOver:        st.DealOver,
+		Outcome:         st.Outcome,
+		Rules:           st.rules(),
+	}
+	if st.Closed || len(st.Stock) == 0 {
+		v.TrumpCard = -1
+	}
+	if st.plays(seat) {
+		v.Hand = append(v.Hand, st.Hands[seat]...)
+		v.Won = append(v.Won, st.Won[seat]...)
+		v.OpponentHandSize = len(st.Hands[st.opponent(seat)])
+	}
+	return v
+}
+
+// plays reports whether seat takes part in the deal.
+func (st State) plays(seat int) bool {
+	return seat >= 0 && seat < len(st.Hands) && !(len(st.Hands) == 3 && seat == st.SitOut)
+}
+
+// opponent returns the seat playing against seat: the other seat in a
+// two-player deal, the other seat besides the dealer with three.
+func (st State) opponent(seat int) int {
+	if len(st.Hands) == 3 {
+		return 3 - seat - st.SitOut
+	}
+	return 1 - seat
+}
+
+// end finishes the deal in favour of winner.
+func (st *State) end(winner int, reason string) {
+	rules := st.rules()
+	var gp int
+	switch {
+	case reason == ReasonFalseClaim:
+		gp = penalty(2, st.Tricks[winner])
+	case st.ClosedBy >= 0 && winner != st.ClosedBy && rules.ClosePenalty > 0:
+		gp = penalty(rules.ClosePenalty, st.OpponentTricksAtClose)
+	case st.ClosedBy >= 0 && winner == st.ClosedBy:
+		gp = gamePoints(st.OpponentPointsAtClose, st.OpponentTricksAtClose, rules.Target)
+	default:
+		gp = gamePoints(st.Scores[st.opponent(winner)], st.Tricks[st.opponent(winner)], rules.Target)
+	}
+	st.Winner = winner
+	st.DealOver = true
+	st.Outcome = &Outcome{Winner: winner, GamePoints: gp, Reason: reason}
+}
+
+// penalty is what a seat wins when the opponent fails a close or claims 66
+// falsely: base game points, one more if the seat had taken no trick.
+func penalty(base, tricks int) int {
+	if tricks == 0 {
+		return base + 1
+	}
+	return base
+}
+
+// gamePoints scores a won deal by the loser's card points and tricks.
+func gamePoints(loserScore, loserTricks, target int) int {
+	switch {
+	case loserTricks == 0:
+		return 3
+	case loserScore < target/2:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// clone returns a copy of st that shares no slices with it, so Apply never
+// mutates its input. Per-seat slices are sized to the seats in Hands.
+func (st State) clone() State {
+	n := len(st.Hands)
+	hands, won := make([][]int, n), make([][]int, n)
+	for i := range hands {
+		hands[i] = slices.Clone(st.Hands[i])
+		if i < len(st.Won) {
+			won[i] = slices.Clone(st.Won[i])
+		}
+	}
+	st.Hands, st.Won = hands, won
+	st.Scores = seatInts(st.Scores, n)
+	st.Tricks = seatInts(st.Tricks, n)
+	st.Pending = seatInts(st.Pending, n)
+	st.Stock = slices.Clone(st.Stock)
+	st.Trick = slices.Clone(st.Trick)
+	st.LastTrick = slices.Clone(st.LastTrick)
+	st.Marriages = slices.Clone(st.Marriages)
+	st.MustPlay = slices.Clone(st.MustPlay)
+	return st
+}
+
+// seatInts returns a copy of xs with one entry per seat.
+func seatInts(xs []int, n int) []int {
+	out := make([]int, n)
+	copy(out, xs)
+	return out
+}
+
+// newDeck returns the 24-card deck, the 20-card deck without nines or the
+// 32-card deck with eights and sevens.
+func newDeck(size int) []int {
+	d := make([]int, 0, size)
 	for _, s := range suits {
-		for _, rv := range rankOrder {
+		for _, rv := range rankOrder[:size/len(suits)] {
 			d = append(d, card(s, rv))
 		}
 	}
@@ -229,19 +611,61 @@ func remove(xs []int, v int) []int {
 	}
 	return xs
 }
-func trickWinner(lead, follow int, trump int) int {
-	ls, fs := cardSuit(lead), cardSuit(follow)
-	if fs == ls {
-		if cardVal(follow) > cardVal(lead) {
-			return 1
+
+// followError checks the follower's duties once the stock is closed or
+// exhausted: follow suit, head the trick with a higher card of that suit if
+// possible, and trump when void in the led suit. Lenient play only requires
+// following suit.
+func followError(hand []int, lead, c, trump int, lenient bool) error {
+	ls := cardSuit(lead)
+	if cardSuit(c) != ls && hasSuit(hand, ls) {
+		return ErrMustFollowSuit.With("card", Card(c))
+	}
+	if lenient {
+		return nil
+	}
+	if cardSuit(c) == ls {
+		if strength(c) < strength(lead) && hasHigher(hand, lead) {
+			return ErrMustHeadTrick.With("card", Card(c))
 		}
-		return 0
+		return nil
 	}
-	if fs == trump && ls != trump {
-		return 1
+	if cardSuit(c) != trump && hasSuit(hand, trump) {
+		return ErrMustTrump.With("card", Card(c))
 	}
-	return 0
+	return nil
 }
+
+// hasHigher reports whether xs holds a card of c's suit that beats c.
+func hasHigher(xs []int, c int) bool {
+	for _, x := range xs {
+		if cardSuit(x) == cardSuit(c) && strength(x) > strength(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// trickWinner returns the index in trick of the winning card: the highest
+// trump, or the highest card of the suit led if no trump was played.
+func trickWinner(trick []int, trump int) int {
+	best := 0
+	for i, c := range trick[1:] {
+		if beats(c, trick[best], trump) {
+			best = i + 1
+		}
+	}
+	return best
+}
+
+// beats reports whether c, played after w, takes the trick from it.
+func beats(c, w, trump int) bool {
+	if cardSuit(c) == cardSuit(w) {
+		return strength(c) > strength(w)
+	}
+	return cardSuit(c) == trump
+}
+
 func getInt(m map[string]any, k string) (int, bool) {
 	if v, ok := m[k]; ok {
 		switch t := v.(type) {
@@ -1,6 +1,9 @@
 package sixtysix
 
 import (
+	"encoding/json"
+	"errors"
+	"slices"
 	"testing"
 
 	"go.rumenx.com/sixtysix/engine"
@@ -10,6 +13,14 @@ func actionPlay(card int) engine.Action {
 	return engine.Action{Type: ActionPlay, Payload: map[string]any{"card": card}}
 }
 
+// withRules returns st playing by DefaultRules changed by edit.
+func withRules(st State, edit func(*RuleSet)) State {
+	r := DefaultRules()
+	edit(&r)
+	st.Rules = &r
+	return st
+}
+
 func TestInitialDealDeterministic(t *testing.T) {
 	g := Game{}
 	a := g.InitialState(42).(State)
@@ -48,6 +59,17 @@ func TestPlayAndTrickResolution(t *testing.T) {
 	}
 }
 
+func TestTrickLedBySecondSeat(t *testing.T) {
+	g := Game{}
+	st := State{Current: 1, Hands: [][]int{{card(Hearts, 0), card(Clubs, 0)}, {card(Hearts, 11), card(Clubs, 2)}}, Closed: true, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
+	ns, _ := g.Apply(st, actionPlay(card(Hearts, 11)))
+	ns, _ = g.Apply(ns, actionPlay(card(Hearts, 0)))
+	st = ns.(State)
+	if st.Current != 1 || st.Scores[1] != 11 || st.Scores[0] != 0 {
+		t.Fatalf("expected seat 1 to win the trick it led, got current=%d scores=%v", st.Current, st.Scores)
+	}
+}
+
 func TestCloseStockEnforcesFollowSuit(t *testing.T) {
 	g := Game{}
 	st := g.InitialState(7).(State)
@@ -89,7 +111,7 @@ func TestDeclareMarriageAndExchange(t *testing.T) {
 
 func TestLastTrickBonus(t *testing.T) {
 	g := Game{}
-	st := State{Current: 0, Scores: [2]int{0, 0}, Hands: [2][]int{{card(Hearts, 0)}, {card(Hearts, 11)}}, Stock: nil, Closed: true, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
+	st := State{Current: 0, Scores: []int{0, 0}, Hands: [][]int{{card(Hearts, 0)}, {card(Hearts, 11)}}, Stock: nil, Closed: true, ClosedBy: -1, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
 	ns, err := g.Apply(st, actionPlay(card(Hearts, 0)))
 	if err != nil {
 		t.Fatalf("lead apply: %v", err)
@@ -106,4 +128,395 @@ func TestLastTrickBonus(t *testing.T) {
 	if st.Scores[1] < 21 {
 		t.Fatalf("expected last trick bonus applied, scores=%v", st.Scores)
 	}
+	// nobody reached 66: the last trick takes the deal
+	if !st.DealOver || st.Winner != 1 || st.Outcome == nil || st.Outcome.Reason != ReasonLastTrick || st.Outcome.GamePoints != 3 {
+		t.Fatalf("expected deal won on last trick, got %+v", st)
+	}
+	if g.ToMove(st) != -1 || !g.Finished(st) || g.Validate(st, actionPlay(card(Hearts, 0))) == nil {
+		t.Fatalf("finished deal should accept no actions")
+	}
+}
+
+func TestReaching66EndsDeal(t *testing.T) {
+	g := Game{}
+	st := State{Current: 0, Scores: []int{50, 40}, Tricks: []int{3, 2}, Hands: [][]int{{card(Hearts, 11), card(Clubs, 0)}, {card(Hearts, 10), card(Clubs, 2)}}, Closed: true, ClosedBy: -1, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
+	st = withRules(st, func(r *RuleSet) { r.AutoWin = true })
+	ns, _ := g.Apply(st, actionPlay(card(Hearts, 11)))
+	ns, _ = g.Apply(ns, actionPlay(card(Hearts, 10)))
+	st = ns.(State)
+	if !st.DealOver || st.Outcome.Winner != 0 || st.Outcome.Reason != ReasonReached66 || st.Outcome.GamePoints != 1 {
+		t.Fatalf("expected seat 0 to win by reaching 66, got %+v", st.Outcome)
+	}
+}
+
+func TestViewForHidesOpponentHand(t *testing.T) {
+	g := Game{}
+	st := g.InitialState(5).(State)
+	v := g.ViewFor(st, 1).(PlayerView)
+	if len(v.Hand) != len(st.Hands[1]) || v.Hand[0] != st.Hands[1][0] {
+		t.Fatalf("expected own hand in view: %+v", v)
+	}
+	if v.OpponentHandSize != len(st.Hands[0]) || v.StockCount != len(st.Stock) {
+		t.Fatalf("unexpected counts: %+v", v)
+	}
+	spec := g.ViewFor(st, 7).(PlayerView)
+	if len(spec.Hand) != 0 || spec.OpponentHandSize != 0 {
+		t.Fatalf("spectator should not see a hand: %+v", spec)
+	}
+}
+
+// legacyState decodes a deal in the original JSON shape, without the
+// per-seat fields added since: seat 0 leads holding the nine of trumps.
+func legacyState(t *testing.T) State {
+	t.Helper()
+	raw := `{"current":0,"scores":[0,0],"hands":[[300,211],[11,10]],"stock":[310,204],"closed":false,"trumpSuit":3,"trumpCard":311,"trick":null,"winner":-1}`
+	var st State
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return st
+}
+
+func TestViewForLegacyState(t *testing.T) {
+	v := Game{}.ViewFor(legacyState(t), 0).(PlayerView)
+	if len(v.Hand) != 2 || len(v.Won) != 0 || len(v.Tricks) != 2 || len(v.Pending) != 2 || v.OpponentHandSize != 2 {
+		t.Fatalf("unexpected view of a legacy state: %+v", v)
+	}
+}
+
+func TestValidateLegacyState(t *testing.T) {
+	g := Game{}
+	st := legacyState(t)
+	if err := g.Validate(st, engine.Action{Type: ActionExchange}); !errors.Is(err, ErrExchangeNeedsTrick) {
+		t.Fatalf("expected ErrExchangeNeedsTrick, got %v", err)
+	}
+	if got := g.LegalActions(st); len(got) == 0 {
+		t.Fatalf("expected legal actions for a legacy state")
+	}
+}
+
+func TestApplyDoesNotMutateInput(t *testing.T) {
+	g := Game{}
+	st := g.InitialState(11).(State)
+	lead := st.Hands[0][0]
+	ns, _ := g.Apply(st, actionPlay(lead))
+	mid := ns.(State)
+	before := append([]int(nil), mid.Trick...)
+	_, _ = g.Apply(mid, actionPlay(mid.Hands[1][0]))
+	if len(mid.Trick) != 1 || mid.Trick[0] != before[0] || !contains(st.Hands[0], lead) {
+		t.Fatalf("input state was mutated: %+v", mid)
+	}
+}
+
+func TestLegalActionsMatchValidate(t *testing.T) {
+	g := Game{}
+	for seed := int64(0); seed < 20; seed++ {
+		st := g.InitialState(seed).(State)
+		for st.Winner == -1 && len(st.Hands[st.Current]) > 0 {
+			legal := g.LegalActions(st)
+			if len(legal) == 0 {
+				t.Fatalf("seed %d: no legal actions in %+v", seed, st)
+			}
+			plays := 0
+			for _, a := range legal {
+				if err := g.Validate(st, a); err != nil {
+					t.Fatalf("seed %d: listed action %+v invalid: %v", seed, a, err)
+				}
+				if a.Type == ActionPlay {
+					plays++
+				}
+			}
+			valid := 0
+			for _, c := range st.Hands[st.Current] {
+				if g.Validate(st, actionPlay(c)) == nil {
+					valid++
+				}
+			}
+			if plays != valid {
+				t.Fatalf("seed %d: %d plays listed, %d valid", seed, plays, valid)
+			}
+			// walk the deal, preferring the last listed action to exercise closing and
+			// marriages, but without announcing 66 which would end it at once
+			next := legal[len(legal)-1]
+			if next.Type == ActionAnnounce {
+				next = legal[len(legal)-2]
+			}
+			ns, err := g.Apply(st, next)
+			if err != nil {
+				t.Fatalf("seed %d: apply: %v", seed, err)
+			}
+			st = ns.(State)
+		}
+	}
+}
+
+func TestCloserFailingIsPenalised(t *testing.T) {
+	g := Game{}
+	// a trick of two nines scores nothing but still spares the opponent the third point
+	for _, tc := range []struct{ tricks, want int }{{0, 3}, {1, 2}} {
+		st := State{Current: 0, Scores: []int{30, 0}, Tricks: []int{3, tc.tricks}, Hands: [][]int{{card(Hearts, 0)}, {card(Hearts, 11)}}, Stock: []int{card(Clubs, 11), card(Clubs, 10)}, TrumpSuit: Spades, TrumpCard: card(Spades, 0), ClosedBy: -1, Winner: -1}
+		ns, _ := g.Apply(st, engine.Action{Type: ActionCloseStock})
+		st = ns.(State)
+		if st.ClosedBy != 0 || st.OpponentPointsAtClose != 0 || st.OpponentTricksAtClose != tc.tricks {
+			t.Fatalf("expected closer recorded, got %+v", st)
+		}
+		ns, _ = g.Apply(st, actionPlay(card(Hearts, 0)))
+		ns, _ = g.Apply(ns, actionPlay(card(Hearts, 11)))
+		st = ns.(State)
+		o := st.Outcome
+		if o == nil || o.Winner != 1 || o.Reason != ReasonCloserFailed || o.GamePoints != tc.want {
+			t.Fatalf("expected opponent with %d tricks to win %d game points, got %+v", tc.tricks, tc.want, o)
+		}
+	}
+}
+
+func TestCloserScoredAtClose(t *testing.T) {
+	g := Game{}
+	st := State{Current: 0, Scores: []int{60, 30}, Tricks: []int{3, 1}, Hands: [][]int{{card(Clubs, 0), card(Hearts, 11)}, {card(Clubs, 11), card(Hearts, 10)}}, Stock: []int{card(Diamonds, 0), card(Diamonds, 2)}, TrumpSuit: Spades, TrumpCard: card(Spades, 0), ClosedBy: -1, Winner: -1}
+	ns, _ := g.Apply(st, engine.Action{Type: ActionCloseStock})
+	// the opponent passes 33 after the close, then the closer takes the last trick
+	for _, c := range []int{card(Clubs, 0), card(Clubs, 11), card(Hearts, 10), card(Hearts, 11)} {
+		ns, _ = g.Apply(ns, actionPlay(c))
+	}
+	st = ns.(State)
+	if st.Scores[0] != 81 || st.Scores[1] != 41 {
+		t.Fatalf("expected no last trick bonus after closing, scores=%v", st.Scores)
+	}
+	o := st.Outcome
+	if o == nil || o.Winner != 0 || o.Reason != ReasonReached66 || o.GamePoints != 2 {
+		t.Fatalf("expected closer to win 2 game points for the opponent's 30 at close, got %+v", o)
+	}
+}
+
+func TestTricksAndWonPilesTracked(t *testing.T) {
+	g := Game{}
+	st := g.InitialState(3).(State)
+	lead, follow := st.Hands[0][0], st.Hands[1][0]
+	ns, _ := g.Apply(st, actionPlay(lead))
+	ns, _ = g.Apply(ns, actionPlay(follow))
+	st = ns.(State)
+	w := st.LastTrickWinner
+	if st.Tricks[w] != 1 || st.Tricks[1-w] != 0 || len(st.Won[w]) != 2 || len(st.Won[1-w]) != 0 {
+		t.Fatalf("expected one trick for seat %d, got tricks=%v won=%v", w, st.Tricks, st.Won)
+	}
+	if !slices.Equal(st.LastTrick, []int{lead, follow}) || len(st.Trick) != 0 {
+		t.Fatalf("expected last trick %v, got %v", []int{lead, follow}, st.LastTrick)
+	}
+	v := g.ViewFor(st, w).(PlayerView)
+	if len(v.Won) != 2 || !slices.Equal(v.Tricks, st.Tricks) || len(g.ViewFor(st, 1-w).(PlayerView).Won) != 0 {
+		t.Fatalf("unexpected view: %+v", v)
+	}
+}
+
+func TestMarriageDeclaredOnceAndLed(t *testing.T) {
+	g := Game{}
+	declare := engine.Action{Type: ActionDeclare, Payload: map[string]any{"suit": Hearts}}
+	st := State{Current: 0, Hands: [][]int{{card(Hearts, 4), card(Hearts, 3), card(Clubs, 11)}, {card(Hearts, 11), card(Clubs, 0), card(Clubs, 10)}}, Stock: []int{card(Diamonds, 0), card(Diamonds, 2)}, TrumpSuit: Spades, TrumpCard: card(Spades, 0), ClosedBy: -1, Winner: -1}
+	if err := g.Validate(st, declare); err != nil {
+		t.Fatalf("declare: %v", err)
+	}
+	ns, _ := g.Apply(st, declare)
+	st = ns.(State)
+	if st.Scores[0] != 0 || st.Pending[0] != 20 {
+		t.Fatalf("expected 20 pending points before the first trick, got scores=%v pending=%v", st.Scores, st.Pending)
+	}
+	if err := g.Validate(st, declare); err == nil {
+		t.Fatalf("expected second declaration to be rejected")
+	}
+	if err := g.Validate(st, actionPlay(card(Clubs, 11))); err == nil {
+		t.Fatalf("expected lead to be restricted to the marriage")
+	}
+	// lead the queen, lose to the ace: points stay pending
+	ns, _ = g.Apply(st, actionPlay(card(Hearts, 3)))
+	ns, _ = g.Apply(ns, actionPlay(card(Hearts, 11)))
+	st = ns.(State)
+	if st.Current != 1 || st.Scores[1] != 14 || st.Pending[0] != 20 {
+		t.Fatalf("expected seat 1 to win the trick, got current=%d scores=%v pending=%v", st.Current, st.Scores, st.Pending)
+	}
+	// seat 1 leads and loses: the trick goes to seat 0 with the pending marriage
+	ns, _ = g.Apply(st, actionPlay(card(Clubs, 10)))
+	ns, _ = g.Apply(ns, actionPlay(card(Clubs, 11)))
+	st = ns.(State)
+	if st.Current != 0 || st.Scores[0] != 41 || st.Pending[0] != 0 {
+		t.Fatalf("expected seat 0 to collect 21 card points plus the marriage, got current=%d scores=%v pending=%v", st.Current, st.Scores, st.Pending)
+	}
+	st.Hands[0] = append(st.Hands[0], card(Hearts, 3))
+	if err := g.Validate(st, declare); err == nil {
+		t.Fatalf("expected the hearts marriage to stay declared")
+	}
+}
+
+func TestAnnounce66(t *testing.T) {
+	g := Game{}
+	announce := engine.Action{Type: ActionAnnounce}
+	st := State{Current: 0, Scores: []int{50, 40}, Tricks: []int{3, 2}, Hands: [][]int{{card(Hearts, 11), card(Clubs, 0)}, {card(Hearts, 10), card(Clubs, 2)}}, Closed: true, ClosedBy: -1, TrumpSuit: Spades, TrumpCard: card(Spades, 0), Winner: -1}
+	// a false claim hands the deal to the opponent with a penalty
+	ns, _ := g.Apply(st, announce)
+	if o := ns.(State).Outcome; o == nil || o.Winner != 1 || o.Reason != ReasonFalseClaim || o.GamePoints != 2 {
+		t.Fatalf("expected false claim penalty, got %+v", o)
+	}
+	// reaching 66 no longer ends the deal by itself
+	ns, _ = g.Apply(st, actionPlay(card(Hearts, 11)))
+	ns, _ = g.Apply(ns, actionPlay(card(Hearts, 10)))
+	st = ns.(State)
+	if st.DealOver || st.Scores[0] != 71 {
+		t.Fatalf("expected deal to continue until announced, got %+v", st)
+	}
+	if err := g.Validate(st, announce); err != nil {
+		t.Fatalf("announce: %v", err)
+	}
+	ns, _ = g.Apply(st, announce)
+	if o := ns.(State).Outcome; o == nil || o.Winner != 0 || o.Reason != ReasonAnnounced || o.GamePoints != 1 {
+		t.Fatalf("expected announced win, got %+v", o)
+	}
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.AutoWin = true }), announce); err == nil {
+		t.Fatalf("expected announce66 to be rejected with automatic wins")
+	}
+}
+
+func TestStrictFollowObligations(t *testing.T) {
+	g := Game{}
+	st := State{Current: 1, Hands: [][]int{{}, {card(Hearts, 3), card(Hearts, 11), card(Spades, 0), card(Clubs, 10)}}, Closed: true, ClosedBy: 0, TrumpSuit: Spades, TrumpCard: card(Spades, 2), Trick: []int{card(Hearts, 10)}, Winner: -1}
+	cases := []struct {
+		card    int
+		wantErr string
+	}{
+		{card(Clubs, 10), "must follow suit"},
+		{card(Hearts, 3), "must head the trick with a higher card"},
+		{card(Hearts, 11), ""},
+	}
+	for _, tc := range cases {
+		err := g.Validate(st, actionPlay(tc.card))
+		if (err == nil) != (tc.wantErr == "") || (err != nil && err.Error() != tc.wantErr) {
+			t.Fatalf("play %d: got %v, want %q", tc.card, err, tc.wantErr)
+		}
+	}
+	// lenient play only enforces following suit
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.LenientFollow = true }), actionPlay(card(Hearts, 3))); err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+
+	// void in hearts: must trump
+	st.Hands[1] = []int{card(Spades, 0), card(Clubs, 10)}
+	if err := g.Validate(st, actionPlay(card(Clubs, 10))); err == nil || err.Error() != "must trump when unable to follow suit" {
+		t.Fatalf("expected trump obligation, got %v", err)
+	}
+	if err := g.Validate(st, actionPlay(card(Spades, 0))); err != nil {
+		t.Fatalf("trump: %v", err)
+	}
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.LenientFollow = true }), actionPlay(card(Clubs, 10))); err != nil {
+		t.Fatalf("lenient discard: %v", err)
+	}
+}
+
+func TestFinalDrawHandsOutTrumpCard(t *testing.T) {
+	g := Game{}
+	for seed := int64(0); seed < 10; seed++ {
+		st := g.InitialState(seed).(State)
+		trump := st.TrumpCard
+		for !st.DealOver {
+			var play engine.Action
+			for _, a := range g.LegalActions(st) {
+				if a.Type == ActionPlay {
+					play = a
+					break
+				}
+			}
+			if play.Type == "" {
+				t.Fatalf("seed %d: stuck without a legal play: %+v", seed, st)
+			}
+			ns, _ := g.Apply(st, play)
+			st = ns.(State)
+			if len(st.Trick) == 0 && len(st.Stock) == 0 && len(st.Hands[0]) == 6 {
+				if len(st.Hands[1]) != 6 || !(contains(st.Hands[0], trump) || contains(st.Hands[1], trump)) {
+					t.Fatalf("seed %d: expected trump card dealt on the final draw: %+v", seed, st.Hands)
+				}
+			}
+		}
+		if st.Tricks[0]+st.Tricks[1] > 12 {
+			t.Fatalf("seed %d: too many tricks: %v", seed, st.Tricks)
+		}
+	}
+}
+
+func TestExchangeAndCloseRestrictions(t *testing.T) {
+	g := Game{}
+	exchange := engine.Action{Type: ActionExchange}
+	closeStock := engine.Action{Type: ActionCloseStock}
+	st := State{Current: 0, Hands: [][]int{{card(Spades, 0), card(Hearts, 11)}, {card(Clubs, 0), card(Clubs, 2)}}, Stock: []int{card(Diamonds, 0), card(Diamonds, 2)}, TrumpSuit: Spades, TrumpCard: card(Spades, 11), ClosedBy: -1, Winner: -1, Tricks: []int{0, 0}}
+	if err := g.Validate(st, exchange); err == nil || err.Error() != "exchange only after winning a trick" {
+		t.Fatalf("expected exchange to need a trick, got %v", err)
+	}
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.LenientStock = true }), exchange); err != nil {
+		t.Fatalf("lenient exchange: %v", err)
+	}
+	st.Tricks[0] = 1
+	if err := g.Validate(st, exchange); err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	st.Stock = st.Stock[:1]
+	if err := g.Validate(st, exchange); err == nil {
+		t.Fatalf("expected exchange to be refused with two cards left")
+	}
+	if err := g.Validate(st, closeStock); err == nil {
+		t.Fatalf("expected close to be refused with two cards left")
+	}
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.LenientStock = true }), closeStock); err != nil {
+		t.Fatalf("lenient close: %v", err)
+	}
+	st.Trick = []int{card(Clubs, 0)}
+	if err := g.Validate(withRules(st, func(r *RuleSet) { r.LenientStock = true }), closeStock); err == nil {
+		t.Fatalf("expected close to be refused after the lead")
+	}
+}
+
+func TestThreePlayerDealerSitsOut(t *testing.T) {
+	rules := DefaultRules()
+	rules.Players = 3
+	g := Game{Rules: &rules}
+	st := g.InitialState(7).(State)
+	if g.Seats(st) != 3 || st.SitOut != 2 || st.Current != 0 || len(st.Hands[2]) != 0 || len(st.Hands[0]) != 6 || len(st.Hands[1]) != 6 {
+		t.Fatalf("unexpected three-player deal: sitOut=%d current=%d hands=%v", st.SitOut, st.Current, st.Hands)
+	}
+	if v := g.ViewFor(st, 2).(PlayerView); len(v.Hand) != 0 || v.OpponentHandSize != 0 || len(v.Scores) != 3 {
+		t.Fatalf("dealer should see a spectator view: %+v", v)
+	}
+	if v := g.ViewFor(st, 1).(PlayerView); len(v.Hand) != 6 || v.OpponentHandSize != 6 {
+		t.Fatalf("unexpected view for seat 1: %+v", v)
+	}
+	for !st.DealOver {
+		if st.Current == st.SitOut {
+			t.Fatalf("dealer asked to move: %+v", st)
+		}
+		var play engine.Action
+		for _, a := range g.LegalActions(st) {
+			if a.Type == ActionPlay {
+				play = a
+				break
+			}
+		}
+		ns, err := g.Apply(st, play)
+		if err != nil {
+			t.Fatalf("play: %v", err)
+		}
+		st = ns.(State)
+	}
+	if st.Outcome.Winner == 2 || st.Tricks[2] != 0 || st.Tricks[0]+st.Tricks[1] != 12 {
+		t.Fatalf("unexpected end: outcome %+v tricks %v", st.Outcome, st.Tricks)
+	}
+}
+
+func TestTwoPlayerStateKeepsTwoSeats(t *testing.T) {
+	st := Game{}.InitialState(1).(State)
+	b, err := json.Marshal(st)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back State
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(back.Hands) != 2 || len(back.Scores) != 2 || len(back.Tricks) != 2 || back.SitOut != -1 {
+		t.Fatalf("unexpected round trip: %s", b)
+	}
 }
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.rumenx.com/sixtysix/engine"
+)
+
+// DefaultSnapshotEvery is the snapshot interval used when NewEventSourced is
+// given none.
+const DefaultSnapshotEvery = 16
+
+// EventSourced is a threadsafe in-memory store whose source of truth is each
+// session's record stream. State is never taken from the engine: reads project
+// it from the latest snapshot plus the records after it, using the registered
+// games, and a new snapshot is taken every SnapshotEvery records.
+type EventSourced struct {
+	mu      sync.RWMutex
+	every   int
+	games   map[string]engine.Game
+	streams map[string]*stream
+	results map[string]*results
+}
+
+// stream is the record log of one session.
+type stream struct {
+	// session holds the fields that records do not cover, such as GameName,
+	// Tokens and Takeback.
+	session  engine.Session
+	records  []engine.Record
+	snapshot snapshot
+}
+
+// snapshot is a projected state at a version; version 0 is before any record.
+type snapshot struct {
+	version int
+	state   any
+}
+
+// NewEventSourced returns an event-sourced store projecting the given games,
+// snapshotting every snapshotEvery records (DefaultSnapshotEvery if <= 0).
+func NewEventSourced(snapshotEvery int, games ...engine.Game) *EventSourced {
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+	es := &EventSourced{
+		every:   snapshotEvery,
+		games:   make(map[string]engine.Game),
+		streams: make(map[string]*stream),
+		results: make(map[string]*results),
+	}
+	for _, g := range games {
+		es.Register(g)
+	}
+	return es
+}
+
+// Register adds g, replacing a game of the same name, e.g. with a fixed Apply
+// before calling Reproject.
+func (es *EventSourced) Register(g engine.Game) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.games[g.Name()] = g
+}
+
+// Create ignores the state in s and projects it from history, snapshotting
+// where append would have.
+func (es *EventSourced) Create(ctx context.Context, s engine.Session, history []engine.Record) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if _, ok := es.streams[s.ID]; ok {
+		return errors.New("store: duplicate id")
+	}
+	if err := checkHistory(s, history); err != nil {
+		return err
+	}
+	st := &stream{session: meta(s), records: append([]engine.Record(nil), history...)}
+	if err := es.reproject(st); err != nil {
+		return err
+	}
+	es.streams[s.ID] = st
+	return nil
+}
+
+// meta strips s of the fields projected from records.
+func meta(s engine.Session) engine.Session {
+	s.State, s.Version, s.Finished = nil, 0, false
+	return s
+}
+
+// Get projects the session.
+func (es *EventSourced) Get(ctx context.Context, id string) (engine.Session, bool, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	st, ok := es.streams[id]
+	if !ok {
+		return engine.Session{}, false, nil
+	}
+	s, err := es.project(st, st.snapshot)
+	return s, err == nil, err
+}
+
+func (es *EventSourced) List(ctx context.Context, gameName string, offset, limit int) ([]engine.Session, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	all := make([]engine.Session, 0)
+	for _, st := range es.streams {
+		if gameName != "" && st.session.GameName != gameName {
+			continue
+		}
+		s, err := es.project(st, st.snapshot)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	if offset > len(all) {
+		return []engine.Session{}, nil
+	}
+	end := offset + limit
+	if limit <= 0 || end > len(all) {
+		end = len(all)
+	}
+	return all[offset:end], nil
+}
+
+func (es *EventSourced) Delete(ctx context.Context, id string) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if _, ok := es.streams[id]; !ok {
+		return engine.ErrSessionNotFound
+	}
+	delete(es.streams, id)
+	delete(es.results, id)
+	return nil
+}
+
+func (es *EventSourced) LoadResult(ctx context.Context, id, key string) (engine.Session, bool, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	r, ok := es.results[id]
+	if !ok {
+		return engine.Session{}, false, nil
+	}
+	s, ok := r.byKey[key]
+	return s, ok, nil
+}
+
+// Commit appends r, which must be the record of s.Version, and keeps the
+// fields of s that records do not cover. The state in s is ignored.
+func (es *EventSourced) Commit(ctx context.Context, s engine.Session, expectedVersion int, r engine.Record, key string) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	st, ok := es.streams[s.ID]
+	if !ok {
+		return engine.ErrSessionNotFound
+	}
+	if st.last() != expectedVersion || r.Version != st.last()+1 || r.Version != s.Version {
+		return engine.ErrConflict
+	}
+	if err := es.append(st, r); err != nil {
+		return err
+	}
+	st.session = meta(s)
+	if r.Kind == engine.RecordRewind {
+		delete(es.results, s.ID)
+	}
+	if key != "" {
+		saveResult(es.results, key, s)
+	}
+	return nil
+}
+
+// append adds r to st, or leaves st as it was if the projection fails. It snapshots the
+// projection once SnapshotEvery records have accumulated since the previous
+// snapshot, and after every rewind, whose target may precede the previous
+// snapshot.
+func (es *EventSourced) append(st *stream, r engine.Record) error {
+	st.records = append(st.records, r)
+	if es.due(r, st.snapshot) {
+		from := st.snapshot
+		if r.Kind == engine.RecordRewind {
+			from = snapshot{}
+		}
+		s, err := es.project(st, from)
+		if err != nil {
+			st.records = st.records[:len(st.records)-1]
+			return err
+		}
+		st.snapshot = snapshot{version: s.Version, state: s.State}
+	}
+	return nil
+}
+
+// due reports whether appending r calls for a snapshot after snap.
+func (es *EventSourced) due(r engine.Record, snap snapshot) bool {
+	return r.Kind == engine.RecordRewind || r.Version-snap.version >= es.every
+}
+
+func (es *EventSourced) History(ctx context.Context, id string) ([]engine.Record, error) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	st, ok := es.streams[id]
+	if !ok {
+		return nil, nil
+	}
+	return append([]engine.Record(nil), st.records...), nil
+}
+
+// Reproject discards every snapshot and rebuilds them from the records with
+// the games as currently registered, so that a fix to a game's Apply reaches
+// existing sessions. Sessions that no longer project keep their snapshots and
+// are reported in the returned error.
+func (es *EventSourced) Reproject(ctx context.Context) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	var errs []error
+	for id, st := range es.streams {
+		if err := es.reproject(st); err != nil {
+			errs = append(errs, fmt.Errorf("store: reproject %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reproject replays st from its first record, snapshotting where append
+// would have.
+func (es *EventSourced) reproject(st *stream) error {
+	g, ok := es.games[st.session.GameName]
+	if !ok {
+		return engine.ErrGameNotFound
+	}
+	var snap snapshot
+	for i, r := range st.records {
+		if !es.due(r, snap) {
+			continue
+		}
+		state, err := engine.Project(g, nil, st.records[:i+1])
+		if err != nil {
+			return err
+		}
+		snap = snapshot{version: r.Version, state: state}
+	}
+	st.snapshot = snap
+	return nil
+}
+
+// project builds the session from a snapshot and the records after it.
+func (es *EventSourced) project(st *stream, from snapshot) (engine.Session, error) {
+	g, ok := es.games[st.session.GameName]
+	if !ok {
+		return engine.Session{}, engine.ErrGameNotFound
+	}
+	rest := st.records
+	for len(rest) > 0 && rest[0].Version <= from.version {
+		rest = rest[1:]
+	}
+	state, err := engine.Project(g, from.state, rest)
+	if err != nil {
+		return engine.Session{}, err
+	}
+	last := st.records[len(st.records)-1]
+	s := st.session
+	s.State = state
+	s.Version = last.Version
+	s.UpdatedAt = last.At
+	if f, ok := g.(engine.Finisher); ok {
+		s.Finished = f.Finished(state)
+	}
+	return s, nil
+}
+
+// last returns the version of the last record, or 0.
+func (st *stream) last() int {
+	if len(st.records) == 0 {
+		return 0
+	}
+	return st.records[len(st.records)-1].Version
+}
@@ -0,0 +1,104 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"go.rumenx.com/sixtysix"
+	"go.rumenx.com/sixtysix/engine"
+	"go.rumenx.com/sixtysix/store"
+)
+
+func TestEventSourced_ProjectsEngineSessions(t *testing.T) {
+	ctx := context.Background()
+	es := store.NewEventSourced(3, sixtysix.Game{})
+	e := engine.New(es)
+	e.Register(sixtysix.Game{})
+
+	s, err := e.CreateSessionWithOptions(ctx, "sixtysix", 11, []byte(`{"deckSize":20,"handSize":5}`))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		got, ok, err := es.Get(ctx, s.ID)
+		if err != nil || !ok || got.Version != s.Version || !reflect.DeepEqual(got.State, s.State) {
+			t.Fatalf("projection at version %d differs: %v ok=%v", s.Version, err, ok)
+		}
+		actor := strconv.Itoa(sixtysix.Game{}.ToMove(s.State))
+		actions, err := e.LegalActions(ctx, s.ID, actor)
+		if err != nil || len(actions) == 0 {
+			t.Fatalf("legal actions: %v %d", err, len(actions))
+		}
+		a := actions[0]
+		a.Actor = actor
+		if s, err = e.ApplyAction(ctx, s.ID, a); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+	}
+	if got, _, _ := es.Get(ctx, s.ID); !reflect.DeepEqual(got.State, s.State) || got.Tokens == nil {
+		t.Fatalf("final projection differs: %+v", got)
+	}
+
+	// stale versions conflict
+	next := s
+	next.Version++
+	if err := es.Commit(ctx, next, s.Version-1, engine.Record{Version: next.Version, Kind: engine.RecordAction}, ""); !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if h, _ := es.History(ctx, s.ID); h[len(h)-1].Version != s.Version {
+		t.Fatalf("failed commit appended a record: %+v", h[len(h)-1])
+	}
+
+	if list, err := es.List(ctx, "sixtysix", 0, 10); err != nil || len(list) != 1 {
+		t.Fatalf("list: %v len=%d", err, len(list))
+	}
+	if err := es.Delete(ctx, s.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok, _ := es.Get(ctx, s.ID); ok {
+		t.Fatalf("session should be gone")
+	}
+}
+
+// counter adds each action's "n" to its state; doubled simulates a bug.
+type counter struct{ doubled bool }
+
+func (counter) Name() string                      { return "counter" }
+func (counter) InitialState(int64) any            { return 0 }
+func (counter) Validate(any, engine.Action) error { return nil }
+func (c counter) Apply(s any, a engine.Action) (any, error) {
+	n := int(a.Payload["n"].(float64))
+	if c.doubled {
+		n *= 2
+	}
+	return s.(int) + n, nil
+}
+
+func TestEventSourced_Reproject(t *testing.T) {
+	ctx := context.Background()
+	es := store.NewEventSourced(2, counter{doubled: true})
+	e := engine.New(es)
+	e.Register(counter{})
+
+	s, _ := e.CreateSession(ctx, "counter", 0)
+	for i := 0; i < 2; i++ {
+		if _, err := e.ApplyAction(ctx, s.ID, engine.Action{Type: "add", Payload: map[string]any{"n": float64(1)}}); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+	}
+	// the snapshot at version 2 holds the buggy 2; version 3 is projected
+	// on read with the fix
+	es.Register(counter{})
+	if got, _, _ := es.Get(ctx, s.ID); got.State != 3 {
+		t.Fatalf("expected the buggy snapshot to persist, got %v", got.State)
+	}
+	if err := es.Reproject(ctx); err != nil {
+		t.Fatalf("reproject: %v", err)
+	}
+	if got, _, _ := es.Get(ctx, s.ID); got.State != 2 || got.Version != 3 {
+		t.Fatalf("reprojected: %+v", got)
+	}
+}
@@ -10,27 +10,80 @@ import (
 	"go.rumenx.com/sixtysix/engine"
 )
 
+// maxResults bounds the idempotency keys remembered per session.
+const maxResults = 64
+
 // Memory is a threadsafe in-memory store useful for tests and small deployments.
 type Memory struct {
 	mu       sync.RWMutex
 	sessions map[string]engine.Session
+	results  map[string]*results
+	history  map[string][]engine.Record
+}
+
+// results is a FIFO of idempotency keys and the sessions they produced.
+type results struct {
+	keys  []string
+	byKey map[string]engine.Session
+}
+
+// saveResult remembers s under key among the results of s.ID.
+func saveResult(all map[string]*results, key string, s engine.Session) {
+	r, ok := all[s.ID]
+	if !ok {
+		r = &results{byKey: make(map[string]engine.Session)}
+		all[s.ID] = r
+	}
+	r.save(key, s)
+}
+
+// save remembers s under key, forgetting the oldest key beyond maxResults.
+func (r *results) save(key string, s engine.Session) {
+	if _, seen := r.byKey[key]; !seen {
+		r.keys = append(r.keys, key)
+		if len(r.keys) > maxResults {
+			delete(r.byKey, r.keys[0])
+			r.keys = r.keys[1:]
+		}
+	}
+	r.byKey[key] = s
 }
 
 func NewMemory() *Memory {
-	return &Memory{sessions: make(map[string]engine.Session)}
+	return &Memory{sessions: make(map[string]engine.Session), results: make(map[string]*results), history: make(map[string][]engine.Record)}
 }
 
-func (m *Memory) Create(ctx context.Context, s engine.Session) error {
+func (m *Memory) Create(ctx context.Context, s engine.Session, history []engine.Record) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, ok := m.sessions[s.ID]; ok {
 		return errors.New("store: duplicate id")
 	}
+	if err := checkHistory(s, history); err != nil {
+		return err
+	}
 	// shallow copy
 	m.sessions[s.ID] = s
+	m.history[s.ID] = append([]engine.Record(nil), history...)
+	return nil
+}
+
+// checkHistory rejects a new session's history unless it runs from version 1
+// to the session's version.
+func checkHistory(s engine.Session, history []engine.Record) error {
+	for i, r := range history {
+		if r.Version != i+1 {
+			return errHistory
+		}
+	}
+	if len(history) == 0 || history[len(history)-1].Version != s.Version {
+		return errHistory
+	}
 	return nil
 }
 
+var errHistory = errors.New("store: history must run from version 1 to the session's version")
+
 func (m *Memory) Get(ctx context.Context, id string) (engine.Session, bool, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -38,17 +91,6 @@ func (m *Memory) Get(ctx context.Context, id string) (engine.Session, bool, erro
 	return s, ok, nil
 }
 
-func (m *Memory) Update(ctx context.Context, s engine.Session) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if _, ok := m.sessions[s.ID]; !ok {
-		return engine.ErrSessionNotFound
-	}
-	s.UpdatedAt = time.Now().UTC()
-	m.sessions[s.ID] = s
-	return nil
-}
-
 func (m *Memory) List(ctx context.Context, gameName string, offset, limit int) ([]engine.Session, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -76,5 +118,55 @@ func (m *Memory) Delete(ctx context.Context, id string) error {
 		return engine.ErrSessionNotFound
 	}
 	delete(m.sessions, id)
+	delete(m.results, id)
+	delete(m.history, id)
+	return nil
+}
+
+func (m *Memory) LoadResult(ctx context.Context, id, key string) (engine.Session, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.results[id]
+	if !ok {
+		return engine.Session{}, false, nil
+	}
+	s, ok := r.byKey[key]
+	return s, ok, nil
+}
+
+func (m *Memory) Commit(ctx context.Context, s engine.Session, expectedVersion int, r engine.Record, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.sessions[s.ID]
+	if !ok {
+		return engine.ErrSessionNotFound
+	}
+	if cur.Version != expectedVersion || r.Version != m.next(s.ID) {
+		return engine.ErrConflict
+	}
+	s.UpdatedAt = time.Now().UTC()
+	m.sessions[s.ID] = s
+	m.history[s.ID] = append(m.history[s.ID], r)
+	if r.Kind == engine.RecordRewind {
+		delete(m.results, s.ID)
+	}
+	if key != "" {
+		saveResult(m.results, key, s)
+	}
 	return nil
 }
+
+// next returns the version the session's next record must have.
+func (m *Memory) next(id string) int {
+	h := m.history[id]
+	if len(h) == 0 {
+		return 1
+	}
+	return h[len(h)-1].Version + 1
+}
+
+func (m *Memory) History(ctx context.Context, id string) ([]engine.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]engine.Record(nil), m.history[id]...), nil
+}
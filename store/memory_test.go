@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -15,13 +16,21 @@ func TestMemory_CRUD(t *testing.T) {
 	s := engine.Session{ID: "a", GameName: "g", Version: 1, CreatedAt: now, UpdatedAt: now}
 
 	// create
-	if err := m.Create(context.Background(), s); err != nil {
+	created := []engine.Record{{Version: 1, Kind: engine.RecordCreate, Seed: 5}}
+	if err := m.Create(context.Background(), s, created); err != nil {
 		t.Fatalf("create: %v", err)
 	}
 	// duplicate
-	if err := m.Create(context.Background(), s); err == nil {
+	if err := m.Create(context.Background(), s, created); err == nil {
 		t.Fatalf("expected duplicate error")
 	}
+	// history not ending at the session's version
+	if err := m.Create(context.Background(), engine.Session{ID: "b", Version: 2}, created); err == nil {
+		t.Fatalf("expected history error")
+	}
+	if _, ok, _ := m.Get(context.Background(), "b"); ok {
+		t.Fatalf("rejected session should not be stored")
+	}
 
 	// get
 	got, ok, err := m.Get(context.Background(), "a")
@@ -29,20 +38,50 @@ func TestMemory_CRUD(t *testing.T) {
 		t.Fatalf("get: %v ok=%v got=%+v", err, ok, got)
 	}
 
-	// update
-	got.Version = 2
-	if err := m.Update(context.Background(), got); err != nil {
-		t.Fatalf("update: %v", err)
-	}
-
 	// list
 	list, err := m.List(context.Background(), "g", 0, 10)
 	if err != nil || len(list) != 1 {
 		t.Fatalf("list: %v len=%d", err, len(list))
 	}
 
+	// commit: a record out of order leaves the session untouched
+	got.Version = 2
+	if err := m.Commit(context.Background(), got, 1, engine.Record{Version: 3, Kind: engine.RecordAction}, ""); !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if cur, _, _ := m.Get(context.Background(), "a"); cur.Version != 1 {
+		t.Fatalf("failed commit changed the session: %+v", cur)
+	}
+	if err := m.Commit(context.Background(), got, 1, engine.Record{Version: 2, Kind: engine.RecordAction, Action: &engine.Action{Type: "x"}}, "k"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if h, err := m.History(context.Background(), "a"); err != nil || len(h) != 2 || h[0].Seed != 5 || h[1].Action.Type != "x" {
+		t.Fatalf("history: %v %+v", err, h)
+	}
+	// stale expected version
+	if err := m.Commit(context.Background(), got, 1, engine.Record{Version: 3, Kind: engine.RecordAction}, ""); !errors.Is(err, engine.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if err := m.Commit(context.Background(), engine.Session{ID: "missing"}, 0, engine.Record{}, ""); !errors.Is(err, engine.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	// idempotency results saved by Commit
+	if r, ok, err := m.LoadResult(context.Background(), "a", "k"); err != nil || !ok || r.Version != 2 {
+		t.Fatalf("load result: %v ok=%v r=%+v", err, ok, r)
+	}
+	if _, ok, _ := m.LoadResult(context.Background(), "a", "other"); ok {
+		t.Fatalf("unexpected result for unknown key")
+	}
+
 	// delete
 	if err := m.Delete(context.Background(), "a"); err != nil {
 		t.Fatalf("delete: %v", err)
 	}
+	if _, ok, _ := m.LoadResult(context.Background(), "a", "k"); ok {
+		t.Fatalf("results should be dropped with the session")
+	}
+	if h, _ := m.History(context.Background(), "a"); len(h) != 0 {
+		t.Fatalf("history should be dropped with the session")
+	}
 }